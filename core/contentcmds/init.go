@@ -8,6 +8,7 @@ func init() {
 	// order matters
 	core.ContentCmds.Append("gotoimplementation_lsproto", GoToImplementationLSProto)
 	core.ContentCmds.Append("gotodefinition_lsproto", GoToDefinitionLSProto)
+	core.ContentCmds.Append("lsprotocodeaction", LSProtoCodeAction)
 
 	//// "gopls query" might work where lsproto might fail (no views in session)
 	//core.ContentCmds.Append("gotodefinition_golang", GoToDefinitionGolang)
@@ -0,0 +1,61 @@
+package contentcmds
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// Applies a code action listed by the "LsprotoCodeActions" internal cmd.
+func LSProtoCodeAction(ctx context.Context, erow *core.ERow, index int) (error, bool) {
+	ta := erow.Row.TextArea
+
+	// limit reading
+	rd := iorw.NewLimitedReaderAtPad(ta.RW(), index, index, 1000)
+
+	id, err := codeActionId(rd, index)
+	if err != nil {
+		return nil, false
+	}
+
+	// timeout for the cmd to run (can have server requests to apply edits)
+	timeout := 8 * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return erow.Ed.LSProtoMan.CodeActionApply(ctx, id), true
+}
+
+//----------
+
+var codeActionIdRe = regexp.MustCompile(`LsprotoCodeAction ([0-9]+):`)
+
+func codeActionId(rd iorw.ReaderAt, index int) (int, error) {
+	src, err := iorw.ReadFastFull(rd)
+	if err != nil {
+		return 0, err
+	}
+
+	// line at index
+	k := index - rd.Min()
+	i := bytes.LastIndexByte(src[:k], '\n') + 1
+	j := bytes.IndexByte(src[k:], '\n')
+	if j < 0 {
+		j = len(src)
+	} else {
+		j += k
+	}
+	line := src[i:j]
+
+	m := codeActionIdRe.FindSubmatch(line)
+	if m == nil {
+		return 0, fmt.Errorf("code action not found")
+	}
+	return strconv.Atoi(string(m[1]))
+}
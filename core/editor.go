@@ -138,6 +138,9 @@ func (ed *Editor) init(opt *Options) error {
 func (ed *Editor) initLSProto(opt *Options) {
 	// language server protocol manager
 	ed.LSProtoMan = lsproto.NewManager(ed.Message)
+	ed.LSProtoMan.OnDiagnostics = ed.onLSProtoDiagnostics
+	ed.LSProtoMan.ApplyOpenEditsFn = ed.lsprotoApplyOpenEdits
+	ed.LSProtoMan.OnProgress = ed.onLSProtoProgress
 	for _, reg := range opt.LSProtos.regs {
 		ed.LSProtoMan.Register(reg)
 	}
@@ -780,6 +783,11 @@ func (ed *Editor) handleGlobalShortcuts(ev any) (handled bool) {
 			}
 		}
 
+		// keep showing while typing (ex: signature help)
+		if _, ok := t.Event.(*event.KeyDown); ok && ed.ifbw.keepOnKeys {
+			autoCloseInfo = false
+		}
+
 		if autoCloseInfo {
 			ed.UI.Root.ContextFloatBox.AutoClose(t.Event, t.Point)
 			if !ed.ifbw.ui().Visible() {
@@ -817,12 +825,14 @@ func (ed *Editor) cancelERowInfosCmds() {
 
 func (ed *Editor) cancelInfoFloatBox() {
 	ed.ifbw.Cancel()
+	ed.ifbw.keepOnKeys = false
 	cfb := ed.ifbw.ui()
 	cfb.Hide()
 }
 
 func (ed *Editor) toggleInfoFloatBox() {
 	ed.ifbw.Cancel() // cancel previous run
+	ed.ifbw.keepOnKeys = false
 
 	// toggle
 	cfb := ed.ifbw.ui()
@@ -903,6 +913,16 @@ func (ed *Editor) toggleInfoFloatBox() {
 		// ui feedback while loading
 		v := fmt.Sprintf("Loading lsproto(%v)...", lang.Reg.Language)
 		showAsync(v)
+		// lsproto hover (only for file content)
+		if ta == erow.Row.TextArea && erow.Info.IsFileButNotDir() {
+			s, err := ed.LSProtoMan.TextDocumentHoverString(ctx, erow.Info.Name(), ta.RW(), ta.CursorIndex())
+			if err != nil {
+				ed.Error(err)
+			} else if s != "" {
+				showAsync(s)
+				return
+			}
+		}
 		// lsproto autocomplete
 		s, err := ed.lsprotoManAutoComplete(ctx, ta, erow)
 		if err != nil {
@@ -927,6 +947,13 @@ func (ed *Editor) lsprotoManAutoComplete(ctx context.Context, ta *ui.TextArea, e
 	return s, nil
 }
 
+// Not UI safe.
+func (ed *Editor) lsprotoDocumentClose(filename string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	_ = ed.LSProtoMan.DocumentClose(ctx, filename) // best effort
+}
+
 //----------
 
 func (ed *Editor) NodeERow(node widget.Node) (*ERow, bool) {
@@ -976,12 +1003,25 @@ func (ed *Editor) SetAnnotations(annotator Annotator, ta *ui.TextArea, selIndex
 }
 func (ed *Editor) setAnnotations2(annotator Annotator, ta *ui.TextArea, selIndex int, entries *drawutil.AnnotationGroup) {
 
+	// find erow from textarea
+	erow, hasERow := (*ERow)(nil), false
+	for _, e := range ed.ERows() {
+		if e.Row.TextArea == ta {
+			erow, hasERow = e, true
+			break
+		}
+	}
+
 	restoreGoDebugAnnotations := func() {
-		// find erow info from textarea
-		for _, erow := range ed.ERows() {
-			if erow.Row.TextArea == ta {
-				ed.GoDebug.UpdateInfoAnnotations(erow.Info)
-			}
+		if hasERow {
+			ed.GoDebug.UpdateInfoAnnotations(erow.Info)
+		}
+	}
+	// lsproto diagnostics are shown when no other annotations are set
+	lsprotoAnnotationsIfOff := func(anno *Annotation) {
+		if !anno.entries.On() && hasERow && erow.lsprotoAnns.On() {
+			anno.index = -1
+			anno.entries = erow.lsprotoAnns
 		}
 	}
 
@@ -995,12 +1035,27 @@ func (ed *Editor) setAnnotations2(annotator Annotator, ta *ui.TextArea, selIndex
 		if ed.InlineComplete.IsOn(ta) {
 			return
 		}
+		lsprotoAnnotationsIfOff(annotation)
 		annotation.set()
 	case AnnotatorInlineComplete:
+		lsprotoAnnotationsIfOff(annotation)
 		annotation.set()
-		if !annotation.entries.On() {
+		if !entries.On() {
 			restoreGoDebugAnnotations()
 		}
+	case AnnotatorLSProto:
+		if !hasERow {
+			return
+		}
+		erow.lsprotoAnns = entries
+		if ed.InlineComplete.IsOn(ta) {
+			return
+		}
+		// godebug annotations have priority
+		if erow.Info.HasRowState(ui.RowStateAnnotations) {
+			return
+		}
+		annotation.set()
 	default:
 		panic("todo")
 	}
@@ -1019,6 +1074,13 @@ func (ed *Editor) AnnotationsHandled(erow *ERow, ev *ui.TextAreaInlineCompleteEv
 //----------
 
 func (ed *Editor) runPreSaveHooks(ctx context.Context, info *ERowInfo, b []byte) ([]byte, error) {
+	// lsproto format option replaces the presavehooks
+	if lang, err := ed.LSProtoMan.LangManager(info.Name()); err == nil {
+		if lang.Reg.HasOptional("format") {
+			return ed.runLSProtoFormat(ctx, info, b)
+		}
+	}
+
 	ext := filepath.Ext(info.Name())
 	for _, h := range ed.preSaveHooks {
 		for _, e := range h.Exts {
@@ -1048,6 +1110,15 @@ func (ed *Editor) runPreSaveHook(ctx context.Context, info *ERowInfo, content []
 	return osutil.RunCmdStdin(ctx2, dir, r, cmd2...)
 }
 
+func (ed *Editor) runLSProtoFormat(ctx context.Context, info *ERowInfo, b []byte) ([]byte, error) {
+	// timeout for the server to reply
+	timeout := 5 * time.Second
+	ctx2, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return ed.LSProtoMan.TextDocumentFormattingBytes(ctx2, info.Name(), b)
+}
+
 //----------
 
 func (ed *Editor) loadSessions() (*Sessions, error) {
@@ -1117,6 +1188,7 @@ const (
 	AnnotatorGoDebug Annotator = iota
 	AnnotatorGoDebugStart
 	AnnotatorInlineComplete
+	AnnotatorLSProto
 )
 
 //----------
@@ -1143,6 +1215,8 @@ type InfoFloatBoxWrap struct {
 	ed   *Editor
 	ctx  context.Context
 	canc context.CancelFunc
+
+	keepOnKeys bool // don't auto close on key down (ex: signature help while typing)
 }
 
 func NewInfoFloatBox(ed *Editor) *InfoFloatBoxWrap {
@@ -34,6 +34,10 @@ type ERow struct {
 	colorizeOpts ERowColorizeOpts
 	optTemu      *ERowTermEmu
 
+	lsprotoAnns       *drawutil.AnnotationGroup // lsproto diagnostics
+	lsprotoSemTokens  ERowSemanticTokens
+	lsprotoInlayHints ERowInlayHints
+
 	ctx       context.Context // erow general context
 	cancelCtx context.CancelFunc
 
@@ -163,6 +167,7 @@ func (erow *ERow) Reload2(firstLoad bool) error {
 				if erow0 != erow {
 					// use with existing content
 					erow.Info.setRWFromMaster(erow0)
+					erow.updateLSProtoDiagnostics()
 					return nil
 				}
 			}
@@ -183,6 +188,7 @@ func (erow *ERow) Reload2(firstLoad bool) error {
 		} else {
 			erow.Info.SetRowsBytes(b)
 		}
+		erow.updateLSProtoDiagnostics()
 		return nil
 	default:
 		info := erow.Info
@@ -256,6 +262,10 @@ func (erow *ERow) initHandlers() {
 	row.TextArea.EvReg.Add(ui.TextAreaBoundsChangeEventId, func(ev0 any) {
 		erow.onTextAreaBoundsChange()
 	})
+	// textarea scroll/resize
+	row.TextArea.EvReg.Add(ui.TextAreaLayoutEventId, func(ev0 any) {
+		erow.lsprotoInlayHintsOnLayout()
+	})
 	row.TextArea.EvReg.Add(ui.TextAreaThemeEventId, func(ev0 any) {
 		if erow.optTemu != nil {
 			erow.optTemu.emu.NeedScreenSync()
@@ -323,7 +333,10 @@ func (erow *ERow) initHandlers() {
 				}
 			case evt.KeySym == event.KSymEscape:
 				erow.Exec.Stop()
+				erow.cancelLSProtoProgress()
 			}
+			// lsproto signature help
+			erow.lsprotoSignatureHelpOnKeyDown(evt)
 		case *event.MouseDown:
 			erow.Info.UpdateActiveRowState(erow)
 		case *event.MouseEnter:
@@ -364,6 +377,14 @@ func (erow *ERow) initHandlers() {
 			erow.Ed.Watcher.Remove(erow.Info.Name())
 		}
 
+		erow.stopLSProtoSemanticTokensTimer()
+		erow.stopLSProtoInlayHintsTimer()
+
+		// close lsproto document
+		if erow.Info.IsFileButNotDir() && len(erow.Info.ERows) == 0 {
+			go erow.Ed.lsprotoDocumentClose(erow.Info.Name())
+		}
+
 		// add to reopener to allow to reopen later if needed
 		if !erow.Info.IsSpecial() {
 			erow.Ed.RowReopener.Add(row)
@@ -481,6 +502,7 @@ func (erow *ERow) parseToolbarVars() {
 	erow.colorizeOpts = colorizeOpts
 	ta.EnableGitColorize(colorizeOpts.git)
 	ta.EnableSyntaxHighlight(colorizeOpts.syntax)
+	erow.enableLSProtoSemanticTokens(colorizeOpts.semantic)
 	if oldColorizeOpts.termGrayscale != colorizeOpts.termGrayscale && erow.optTemu != nil {
 		erow.optTemu.tui.render.useGrayscale = colorizeOpts.termGrayscale
 		erow.optTemu.emu.NeedScreenSync()
@@ -519,6 +541,19 @@ func (erow *ERow) parseToolbarVars() {
 
 	//----------
 
+	// $inlayHints: lsproto inlay hints for the visible range
+	inlayHints := false
+	if v, ok := vmap["$inlayHints"]; ok {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "0", "false", "no", "off":
+		default:
+			inlayHints = true
+		}
+	}
+	erow.enableLSProtoInlayHints(inlayHints)
+
+	//----------
+
 	if erow.optTemu == nil && !erow.Info.IsDir() {
 		ta.SetThemeFontFace(erow.fontOpts.face)
 	}
@@ -794,6 +829,8 @@ func (erow *ERow) parseColorizeOpts(v string) ERowColorizeOpts {
 			opts.git = set
 		case "syntax":
 			opts.syntax = set
+		case "semantic":
+			opts.semantic = set
 		}
 	}
 	return opts
@@ -1003,6 +1040,7 @@ type ERowColorizeOpts struct {
 	termGrayscale bool
 	git           bool
 	syntax        bool
+	semantic      bool // lsproto semantic tokens
 }
 
 type ERowFontOpts struct {
@@ -519,6 +519,18 @@ func (info *ERowInfo) HandleRWEvWrite2(erow *ERow, ev *iorw.RWEvWrite2) {
 		e.Row.TextArea.HandleRWWrite2(ev)
 	}
 
+	// keep lsproto open document in sync
+	if ev.Changed {
+		rd := erow.Row.TextArea.RW()
+		if err := info.Ed.LSProtoMan.DocumentWrite(info.Name(), rd, &ev.RWEvWrite); err != nil {
+			info.Ed.Error(err)
+		}
+		for _, e := range info.ERows {
+			e.lsprotoSemanticTokensOnWrite(&ev.RWEvWrite)
+			e.lsprotoInlayHintsOnWrite(&ev.RWEvWrite)
+		}
+	}
+
 	info.UpdateEditedRowState()
 }
 
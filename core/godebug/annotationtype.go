@@ -3,6 +3,8 @@ package godebug
 import (
 	"fmt"
 	"go/ast"
+	"go/parser"
+	"strconv"
 	"strings"
 	"unicode"
 )
@@ -18,6 +20,9 @@ const (
 	AnnotationTypeImport  // annotates set of files (importspec)
 	AnnotationTypePackage // annotates set of files
 	AnnotationTypeModule  // annotates set of packages
+
+	AnnotationTypeBreak // not a set: pauses the program before the stmt (only in annotated files)
+	AnnotationTypeLimit // not a set: limits the msgs of the node annotations (only in annotated files)
 )
 
 func AnnotationTypeInString(s string) (AnnotationType, string, error) {
@@ -57,6 +62,10 @@ func AnnotationTypeInString(s string) (AnnotationType, string, error) {
 		at = AnnotationTypeImport
 	case "annotatemodule":
 		at = AnnotationTypeModule
+	case "break":
+		at = AnnotationTypeBreak
+	case "annotate":
+		at = AnnotationTypeLimit
 	default:
 		err := fmt.Errorf("unexpected annotate type: %q", typ)
 		return AnnotationTypeNone, "", err
@@ -68,9 +77,12 @@ func AnnotationTypeInString(s string) (AnnotationType, string, error) {
 		case AnnotationTypeFile:
 		case AnnotationTypePackage:
 		case AnnotationTypeModule:
+		case AnnotationTypeLimit:
 		default:
 			return at, opt, fmt.Errorf("unexpected annotate option: %q", opt)
 		}
+	} else if at == AnnotationTypeLimit {
+		return at, opt, fmt.Errorf("missing annotate option")
 	}
 
 	return at, opt, nil
@@ -80,6 +92,76 @@ func AnnotationTypeInString(s string) (AnnotationType, string, error) {
 //----------
 //----------
 
+// Limits the msgs sent by the annotations of a node (inner nodes override).
+type AnnotationLimit struct {
+	maxHits int    // max msgs per annotation index (zero: no limit)
+	cond    string // go boolean expr evaluated at each annotation (empty: always)
+
+	strMax   int // stringify max length of values (zero: default)
+	strDepth int // stringify max depth of values (zero: default)
+}
+
+// Parses the option of "//godebug:annotate:<opt>". Ex: "maxhits=100", "if=i%10==0", "strmax=1000", "strdepth=10".
+func parseAnnotationLimit(opt string) (*AnnotationLimit, error) {
+	k, v, ok := strings.Cut(opt, "=")
+	if !ok {
+		return nil, fmt.Errorf("expecting key=value: %q", opt)
+	}
+	k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+	lim := &AnnotationLimit{}
+	switch k {
+	case "maxhits":
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("bad maxhits: %q", v)
+		}
+		lim.maxHits = n
+	case "if":
+		if _, err := parser.ParseExpr(v); err != nil {
+			return nil, fmt.Errorf("bad condition: %q: %w", v, err)
+		}
+		lim.cond = v
+	case "strmax", "strdepth":
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("bad %v: %q", k, v)
+		}
+		if k == "strmax" {
+			lim.strMax = n
+		} else {
+			lim.strDepth = n
+		}
+	default:
+		return nil, fmt.Errorf("unexpected annotate option: %q", k)
+	}
+	return lim, nil
+}
+
+// Returns a new limit with the fields set in lim2 overriding lim (can be nil).
+func (lim *AnnotationLimit) merge(lim2 *AnnotationLimit) *AnnotationLimit {
+	u := &AnnotationLimit{}
+	if lim != nil {
+		*u = *lim
+	}
+	if lim2.maxHits != 0 {
+		u.maxHits = lim2.maxHits
+	}
+	if lim2.cond != "" {
+		u.cond = lim2.cond
+	}
+	if lim2.strMax != 0 {
+		u.strMax = lim2.strMax
+	}
+	if lim2.strDepth != 0 {
+		u.strDepth = lim2.strDepth
+	}
+	return u
+}
+
+//----------
+//----------
+//----------
+
 type AnnotationOpt struct {
 	Type    AnnotationType
 	Opt     string
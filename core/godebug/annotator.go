@@ -16,12 +16,18 @@ type Annotator struct {
 
 	typesInfo    *types.Info
 	nodeAnnTypes map[ast.Node]AnnotationType
+	breakNodes   map[ast.Node]bool
+	limitNodes   map[ast.Node]*AnnotationLimit
 
 	fileIndex int
 
 	dopt              *AnnSetDebugOpt
 	debugVarNameIndex int
-	debugNIndexes     int // n indexes were used
+	debugNIndexes     int         // n indexes were used
+	debugMaxHits      map[int]int // map[debugIndex]maxHits (after correcting indexes)
+	lineMaxHits       map[*ast.CallExpr]int
+	condScopes        map[string]*condScope // [cond]
+	testRunFuncLits   map[*ast.FuncLit]bool // subtests: "t.Run(name, func(t *testing.T){...})"
 
 	testModeMainFunc bool
 	hasMainFunc      bool
@@ -41,6 +47,11 @@ func NewAnnotator(fset *token.FileSet, ti *types.Info, dopt *AnnSetDebugOpt) *An
 	ann.simplify = true // always true
 	ann.ctxData.visited = map[ast.Stmt]struct{}{}
 	ann.nodeAnnTypes = map[ast.Node]AnnotationType{}
+	ann.breakNodes = map[ast.Node]bool{}
+	ann.limitNodes = map[ast.Node]*AnnotationLimit{}
+	ann.lineMaxHits = map[*ast.CallExpr]int{}
+	ann.condScopes = map[string]*condScope{}
+	ann.testRunFuncLits = map[*ast.FuncLit]bool{}
 	ann.pkg = ann.typesPkg()
 	return ann
 }
@@ -50,6 +61,7 @@ func NewAnnotator(fset *token.FileSet, ti *types.Info, dopt *AnnSetDebugOpt) *An
 func (ann *Annotator) AnnotateAstFile(astFile *ast.File) {
 	defer func() { // always run, even on error
 		ann.debugNIndexes = ann.correctDebugIndexes(astFile)
+		ann.debugMaxHits = ann.correctedMaxHits()
 
 		// fix issues like "//go:embed" comments staying in place
 		//ann.removeInnerFuncComments(astFile) // failing
@@ -81,6 +93,7 @@ func (ann *Annotator) logf(f string, args ...any) {
 
 func (ann *Annotator) visFile(ctx *Ctx, file *ast.File) error {
 	ctx = ctx.withNoAnnotationsUpdated(file)
+	ctx = ctx.withAnnLimitUpdated(file)
 	for _, decl := range file.Decls {
 		if err := ann.visDecl(ctx, decl); err != nil {
 			return err
@@ -91,6 +104,7 @@ func (ann *Annotator) visFile(ctx *Ctx, file *ast.File) error {
 
 func (ann *Annotator) visDecl(ctx *Ctx, decl ast.Decl) error {
 	ctx = ctx.withNoAnnotationsUpdated(decl)
+	ctx = ctx.withAnnLimitUpdated(decl)
 	switch t := decl.(type) {
 	case *ast.BadDecl:
 		return nil
@@ -112,6 +126,7 @@ func (ann *Annotator) visStmt(ctx *Ctx, stmt ast.Stmt) error {
 
 	ctx = ctx.withFixedDebugIndex(true) // each stmt uses a fixed index
 	ctx = ctx.withNoAnnotationsUpdated(stmt)
+	ctx = ctx.withAnnLimitUpdated(stmt)
 
 	//----------
 
@@ -283,11 +298,16 @@ func (ann *Annotator) visFuncDecl(ctx *Ctx, fd *ast.FuncDecl) error {
 	}
 
 	ctx = ctx.withValue(cidnFuncNode, fd) // ex: returnstmt needs this
+	ctx, gv := ann.withGoIdVar(ctx)
+	defer ann.insertGoIdVar(fd.Body, gv)
 
 	ctx2 := ctx.withStmts(&fd.Body.List)
 
 	ann.insertDeferRecover(ctx2)
 	_ = ann.insertMainClose(ctx2, fd)
+	if fd.Recv == nil && strings.HasPrefix(fd.Name.Name, "Test") {
+		ann.insertTestStartEnd(ctx2, fd.Type)
+	}
 
 	if name, ok := ann.detectJumps(ctx2, fd); ok {
 		// insert a not annotated step
@@ -317,6 +337,9 @@ func (ann *Annotator) visStmts(ctx *Ctx, stmts *[]ast.Stmt) error {
 	si := newStmtsIter(ctx, stmts)
 	ctx = ctx.withValue(cidStmtsIter, si)
 	return si.iterate(func(stmt ast.Stmt) error {
+		if ann.breakNodes[stmt] {
+			ann.insertBreakStmt(ctx, stmt.Pos())
+		}
 		return ann.visStmt(ctx, stmt)
 	})
 }
@@ -472,8 +495,10 @@ func (ann *Annotator) visAsyncStmt(ctx *Ctx, ce0 **ast.CallExpr) error {
 		ann.insertDebugLineStmt(runFlCtx, de)
 	}
 
-	// funclit that will run later
+	// funclit that will run later (in another goroutine if a go stmt)
 	asyncFl := newFuncLit()
+	ctx, gv := ann.withGoIdVar(ctx)
+	defer ann.insertGoIdVar(asyncFl.Body, gv)
 	asyncFlCtx := ctx.withStmts(&asyncFl.Body.List)
 	rs := &ast.ReturnStmt{Results: []ast.Expr{asyncFl}}
 	runFlCtx.insertStmt(rs)
@@ -956,7 +981,7 @@ func (ann *Annotator) visBinaryExprAndOr(ctx *Ctx, be *ast.BinaryExpr) (DebugExp
 	as3 := newAssignStmtA11(value, be.Y)
 	ifsBodyCtx.insertStmt(as3)
 
-	result := ann.newDebugIVi(value)
+	result := ann.newDebugIVi(ctx, value)
 
 	opbl := basicLitInt(int(be.Op), be.Pos())
 	de := ann.newDebugCE("IB", x, opbl, y, result)
@@ -966,6 +991,7 @@ func (ann *Annotator) visBinaryExprAndOr(ctx *Ctx, be *ast.BinaryExpr) (DebugExp
 //----------
 
 func (ann *Annotator) visCallExpr(ctx *Ctx, ce *ast.CallExpr) (DebugExpr, error) {
+	ann.detectTestRunFuncLit(ce)
 
 	ctx2 := ctx.withValue(cidnNameInsteadOfValue, ce.Fun)
 	ctx2 = ctx2.withValue(cidnIsCallExprFun, ce.Fun)
@@ -1053,10 +1079,15 @@ func (ann *Annotator) visFuncLit(ctx *Ctx, fl *ast.FuncLit) (DebugExpr, error) {
 
 	ctx2 := ctx.withResetForFuncLit()
 	ctx2 = ctx2.withValue(cidnFuncNode, fl) // ex: returnstmt
+	ctx2, gv := ann.withGoIdVar(ctx2)
+	defer ann.insertGoIdVar(fl.Body, gv)
 
 	ctx3 := ctx2.withStmts(&fl.Body.List)
 
 	ann.insertDeferRecover(ctx3)
+	if ann.testRunFuncLits[fl] {
+		ann.insertTestStartEnd(ctx3, fl.Type)
+	}
 
 	// visit type inside the body
 	u := (ast.Expr)(fl.Type)
@@ -3,8 +3,11 @@ package godebug
 import (
 	"fmt"
 	"go/ast"
+	"go/parser"
 	"go/token"
 	"go/types"
+	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -42,7 +45,12 @@ func (ann *Annotator) newDebugCE2(fname string, pos token.Pos, des ...DebugExpr)
 
 //----------
 
-func (ann *Annotator) newDebugIVi(e ast.Expr) DebugExpr {
+func (ann *Annotator) newDebugIVi(ctx *Ctx, e ast.Expr) DebugExpr {
+	if lim, ok := ctx.annLimit(); ok && (lim.strMax != 0 || lim.strDepth != 0) {
+		max := basicLitInt(lim.strMax, e.Pos())
+		depth := basicLitInt(lim.strDepth, e.Pos())
+		return ann.newDebugCE("IVi2", DebugExpr(e), max, depth)
+	}
 	return ann.newDebugCE("IVi", DebugExpr(e))
 }
 func (ann *Annotator) newDebugIVs(s string, pos token.Pos) DebugExpr {
@@ -88,8 +96,114 @@ func (ann *Annotator) newDebugLineStmt(ctx *Ctx, de DebugExpr) ast.Stmt {
 		basicLitInt(ctx.getDebugIndex(), token.NoPos),
 		basicLitInt(ann.fset.Position(de.Pos()).Offset, token.NoPos),
 		ast.Expr(de),
+		ann.goIdExpr(ctx),
+	}
+	ce := &ast.CallExpr{Fun: se, Args: args}
+	stmt := ast.Stmt(&ast.ExprStmt{X: ce})
+
+	if lim, ok := ctx.annLimit(); ok {
+		if lim.maxHits > 0 {
+			ann.lineMaxHits[ce] = lim.maxHits
+		}
+		if lim.cond != "" {
+			// new expr at each use (already validated); not used if the identifiers are not visible (ex: loop var at the loop init annotations)
+			if cond, err := parser.ParseExpr(lim.cond); err == nil && ann.condInScope(lim.cond, cond, ann.debugLineScopePos(ctx, de)) {
+				resetPositions(cond)
+				body := &ast.BlockStmt{List: []ast.Stmt{stmt}}
+				stmt = &ast.IfStmt{Cond: cond, Body: body}
+			}
+		}
+	}
+	return stmt
+}
+
+// Position where the scope of the inserted debug line is evaluated.
+func (ann *Annotator) debugLineScopePos(ctx *Ctx, de DebugExpr) token.Pos {
+	pos := de.Pos()
+	if ctx.boolean(cidbInsertStmtAfter) {
+		if stmt, ok := ctx.stmtsIter().current(); ok && stmt.End().IsValid() && stmt.End() > pos {
+			pos = stmt.End()
+		}
+	}
+	return pos
+}
+
+// Keeps track of the conditions that are never in scope (see condsErr).
+func (ann *Annotator) condInScope(condStr string, cond ast.Expr, pos token.Pos) bool {
+	cs, ok := ann.condScopes[condStr]
+	if !ok {
+		cs = &condScope{pos: pos}
+		ann.condScopes[condStr] = cs
+	}
+	name, ok := ann.exprInScope(cond, pos)
+	if ok {
+		cs.inScope = true
+	} else if cs.ident == "" {
+		cs.ident = name
+	}
+	return ok
+}
+
+// Error if a condition was never in scope in the file (ex: typo in an identifier), instead of silently sending all msgs.
+func (ann *Annotator) condsErr() error {
+	u := []string{}
+	for cond, cs := range ann.condScopes {
+		if !cs.inScope {
+			s := fmt.Sprintf("%v: condition %q: identifier not in scope: %v", ann.fset.Position(cs.pos), cond, cs.ident)
+			u = append(u, s)
+		}
+	}
+	if len(u) == 0 {
+		return nil
+	}
+	sort.Strings(u)
+	return fmt.Errorf("annotate: %v", strings.Join(u, "\n"))
+}
+
+// Reports if the identifiers of the expr are visible at pos (true if unable to check), otherwise returns the first identifier not visible.
+func (ann *Annotator) exprInScope(e ast.Expr, pos token.Pos) (string, bool) {
+	if ann.pkg == nil || !pos.IsValid() {
+		return "", true
+	}
+	scope := ann.pkg.Scope().Innermost(pos)
+	if scope == nil {
+		return "", true
 	}
-	return &ast.ExprStmt{X: &ast.CallExpr{Fun: se, Args: args}}
+	name, ok := "", true
+	ast.Inspect(e, func(node ast.Node) bool {
+		if !ok {
+			return false
+		}
+		switch t := node.(type) {
+		case *ast.SelectorExpr:
+			name, ok = ann.exprInScope(t.X, pos) // t.Sel is not in scope
+			return false
+		case *ast.Ident:
+			if _, obj := scope.LookupParent(t.Name, pos); obj == nil {
+				name, ok = t.Name, false
+			}
+		}
+		return true
+	})
+	return name, ok
+}
+
+type condScope struct {
+	pos     token.Pos // first use
+	inScope bool      // at least once
+	ident   string    // first identifier not in scope
+}
+
+func (ann *Annotator) insertBreakStmt(ctx *Ctx, pos token.Pos) {
+	se := &ast.SelectorExpr{
+		X:   &ast.Ident{Name: ann.dopt.PkgName, NamePos: pos},
+		Sel: ast.NewIdent("Break"),
+	}
+	args := []ast.Expr{
+		basicLitInt(ann.fileIndex, token.NoPos),
+		basicLitInt(ann.fset.Position(pos).Offset, token.NoPos),
+	}
+	ctx.insertStmt(&ast.ExprStmt{X: &ast.CallExpr{Fun: se, Args: args}})
 }
 
 //----------
@@ -177,12 +291,12 @@ func (ann *Annotator) resBasic(ctx *Ctx, expr, res ast.Expr) (DebugExpr, error)
 	if te, ok := res.(*tupleExpr); ok {
 		des := []DebugExpr{}
 		for _, e := range te.w {
-			de2 := ann.newDebugIVi(e)
+			de2 := ann.newDebugIVi(ctx, e)
 			des = append(des, de2)
 		}
 		de = ann.newDebugIL(des...)
 	} else {
-		de = ann.newDebugIVi(res)
+		de = ann.newDebugIVi(ctx, res)
 	}
 	// operate on debugexpr
 	if ctx.valueMatch2(cidnResAssignDebugToVar, expr) {
@@ -723,6 +837,21 @@ func (ann *Annotator) correctDebugIndexes(n ast.Node) int {
 	return di
 }
 
+// Max hits by debug index. Must run after the debug indexes are corrected.
+func (ann *Annotator) correctedMaxHits() map[int]int {
+	m := map[int]int{}
+	for ce, n := range ann.lineMaxHits {
+		di, err := strconv.Atoi(ce.Args[1].(*ast.BasicLit).Value)
+		if err != nil {
+			panic(err)
+		}
+		if n2, ok := m[di]; !ok || n < n2 {
+			m[di] = n
+		}
+	}
+	return m
+}
+
 //----------
 
 func (ann *Annotator) removeInnerFuncComments(astFile *ast.File) {
@@ -832,6 +961,54 @@ func (ann *Annotator) insertMainClose(ctx *Ctx, fd *ast.FuncDecl) bool {
 	return true
 }
 
+// Goroutine id of the annotated func, obtained once at the func entry (a func body runs in a single goroutine) instead of at each debug line.
+type goIdVar struct {
+	uses map[*ast.Ident]bool
+}
+
+func (ann *Annotator) withGoIdVar(ctx *Ctx) (*Ctx, *goIdVar) {
+	gv := &goIdVar{uses: map[*ast.Ident]bool{}}
+	return ctx.withValue(cidnGoIdVar, gv), gv
+}
+func (ann *Annotator) goIdExpr(ctx *Ctx) ast.Expr {
+	if v, _, ok := ctx.value(cidnGoIdVar); ok {
+		gv := v.(*goIdVar)
+		id := ast.NewIdent(ann.goIdVarName())
+		gv.uses[id] = true
+		return id
+	}
+	// not inside a func body
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent(ann.dopt.PkgName),
+			Sel: ast.NewIdent("GoId"),
+		},
+	}
+}
+
+// Inserts the var at the start of the body if still used after the annotations (ex: not used if the debug lines were dropped).
+func (ann *Annotator) insertGoIdVar(body *ast.BlockStmt, gv *goIdVar) {
+	used := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && gv.uses[id] {
+			used = true
+		}
+		return !used
+	})
+	if !used {
+		return
+	}
+	as := &ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent(ann.goIdVarName())},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{ann.goIdExpr(newCtx(ann))},
+	}
+	body.List = slices.Insert(body.List, 0, ast.Stmt(as))
+}
+func (ann *Annotator) goIdVarName() string {
+	return ann.dopt.VarPrefix + "g" // no clash with the numbered vars
+}
+
 func (ann *Annotator) insertDeferRecover(ctx *Ctx) {
 	ds := &ast.DeferStmt{
 		Call: &ast.CallExpr{
@@ -844,6 +1021,61 @@ func (ann *Annotator) insertDeferRecover(ctx *Ctx) {
 	ctx.insertStmt(ds)
 }
 
+// Inserts test start/end calls in funcs with a "*testing.T" param (tests and "t.Run" subtests) to allow grouping the msgs by test.
+func (ann *Annotator) insertTestStartEnd(ctx *Ctx, ft *ast.FuncType) {
+	id, ok := ann.testingTParam(ft)
+	if !ok {
+		return
+	}
+	newCall := func(name string) *ast.CallExpr {
+		return &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   ast.NewIdent(ann.dopt.PkgName),
+				Sel: ast.NewIdent(name),
+			},
+			Args: []ast.Expr{ast.NewIdent(id.Name)},
+		}
+	}
+	ctx.insertStmt(&ast.ExprStmt{X: newCall("TestStart")})
+	ctx.insertStmt(&ast.DeferStmt{Call: newCall("TestEnd")})
+}
+func (ann *Annotator) detectTestRunFuncLit(ce *ast.CallExpr) {
+	se, ok := ce.Fun.(*ast.SelectorExpr)
+	if !ok || se.Sel.Name != "Run" || len(ce.Args) != 2 {
+		return
+	}
+	if fl, ok := ce.Args[1].(*ast.FuncLit); ok {
+		ann.testRunFuncLits[fl] = true
+	}
+}
+func (ann *Annotator) testingTParam(ft *ast.FuncType) (*ast.Ident, bool) {
+	if ann.typesInfo == nil || ft.Params == nil || len(ft.Params.List) != 1 {
+		return nil, false
+	}
+	f := ft.Params.List[0]
+	if len(f.Names) != 1 || f.Names[0].Name == "_" {
+		return nil, false
+	}
+	id := f.Names[0]
+	obj := ann.typesInfo.Defs[id]
+	if obj == nil {
+		return nil, false
+	}
+	pt, ok := obj.Type().(*types.Pointer)
+	if !ok {
+		return nil, false
+	}
+	nt, ok := pt.Elem().(*types.Named)
+	if !ok {
+		return nil, false
+	}
+	tn := nt.Obj()
+	if tn.Pkg() == nil || tn.Pkg().Path() != "testing" || tn.Name() != "T" {
+		return nil, false
+	}
+	return id, true
+}
+
 //----------
 
 func (ann *Annotator) updateOsExitCalls(ctx *Ctx, ce *ast.CallExpr) (error, bool) {
@@ -1077,6 +1309,27 @@ func bypassParenExpr(expr ast.Expr) ast.Expr {
 
 //----------
 
+// Sets all positions to token.NoPos. Useful for nodes parsed with another fileset.
+func resetPositions(node ast.Node) {
+	posType := reflect.TypeOf(token.NoPos)
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		v := reflect.ValueOf(n)
+		if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+			return true
+		}
+		v = v.Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.Type() == posType && f.CanSet() {
+				f.SetInt(int64(token.NoPos))
+			}
+		}
+		return true
+	})
+}
+
 func basicLitInt(v int, pos token.Pos) *ast.BasicLit {
 	return &ast.BasicLit{
 		ValuePos: pos,
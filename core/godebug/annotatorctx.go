@@ -220,6 +220,9 @@ func (ctx *Ctx) withFixedDebugIndex(fixed bool) *Ctx {
 func (ctx *Ctx) withResetForFuncLit() *Ctx {
 	ctx2 := newCtx(ctx.ann) // full reset
 	ctx2 = ctx2.withNoAnnotationsInstance2(ctx)
+	if lim, ok := ctx.annLimit(); ok {
+		ctx2 = ctx2.withValue(cidnAnnLimit, lim)
+	}
 	return ctx2
 }
 
@@ -270,6 +273,24 @@ func (ctx *Ctx) withNoAnnotationsUpdated(node ast.Node) *Ctx {
 
 //----------
 
+func (ctx *Ctx) annLimit() (*AnnotationLimit, bool) {
+	v, _, ok := ctx.value(cidnAnnLimit)
+	if !ok {
+		return nil, false
+	}
+	return v.(*AnnotationLimit), true
+}
+func (ctx *Ctx) withAnnLimitUpdated(node ast.Node) *Ctx {
+	lim, ok := ctx.ann.limitNodes[node]
+	if !ok {
+		return ctx
+	}
+	lim0, _ := ctx.annLimit()
+	return ctx.withValue(cidnAnnLimit, lim0.merge(lim))
+}
+
+//----------
+
 func (ctx *Ctx) panic(v any) error {
 	s := fmt.Sprint(v)
 	if u, ok := ctx.curStmtSrc(); ok {
@@ -318,6 +339,8 @@ const (
 	cidnExprs
 	cidnFuncNode
 	cidnNameInsteadOfValue
+	cidnAnnLimit
+	cidnGoIdVar
 
 	cidnResNil
 	cidnResAssignDebugToVar
@@ -370,6 +393,18 @@ func (si *StmtsIter) replace(stmt ast.Stmt) {
 	}
 	si.ctx.setStmtVisited(stmt, true)
 }
+func (si *StmtsIter) current() (ast.Stmt, bool) {
+	if si.stmts == nil {
+		if si.stmt == nil || *si.stmt == nil {
+			return nil, false
+		}
+		return *si.stmt, true
+	}
+	if si.index >= len(*si.stmts) {
+		return nil, false
+	}
+	return (*si.stmts)[si.index], true
+}
 func (si *StmtsIter) insert(stmt ast.Stmt, after bool) {
 	if si.stmts == nil {
 		err := fmt.Errorf("insert: stmts=nil")
@@ -7,6 +7,7 @@ import (
 	"go/token"
 	"go/types"
 	"io/ioutil"
+	"sort"
 	"strings"
 	"sync"
 
@@ -24,6 +25,10 @@ type AnnotatorSet struct {
 		order []*debug.AnnotatorFileData          // ordered
 		index int                                 // counter for new files
 	}
+	maxHits struct {
+		sync.Mutex
+		m map[debug.AfdFileIndex]map[int]int // map[fileIndex]map[debugIndex]maxHits
+	}
 }
 
 func NewAnnotatorSet(fset *token.FileSet) *AnnotatorSet {
@@ -31,12 +36,13 @@ func NewAnnotatorSet(fset *token.FileSet) *AnnotatorSet {
 	annset.fset = fset
 	annset.dopt = newAnnSetDebugOpt()
 	annset.afds.m = map[string]*debug.AnnotatorFileData{}
+	annset.maxHits.m = map[debug.AfdFileIndex]map[int]int{}
 	return annset
 }
 
 //----------
 
-func (annset *AnnotatorSet) AnnotateAstFile(astFile *ast.File, ti *types.Info, nat map[ast.Node]AnnotationType, testModeMainFunc bool) (*Annotator, error) {
+func (annset *AnnotatorSet) AnnotateAstFile(astFile *ast.File, ti *types.Info, nat map[ast.Node]AnnotationType, breaks map[ast.Node]bool, limits map[ast.Node]*AnnotationLimit, testModeMainFunc bool) (*Annotator, error) {
 
 	filename, err := nodeFilename(annset.fset, astFile)
 	if err != nil {
@@ -51,12 +57,23 @@ func (annset *AnnotatorSet) AnnotateAstFile(astFile *ast.File, ti *types.Info, n
 	ann := NewAnnotator(annset.fset, ti, annset.dopt)
 	ann.fileIndex = int(afd.FileIndex)
 	ann.nodeAnnTypes = nat
+	ann.breakNodes = breaks
+	ann.limitNodes = limits
 	ann.testModeMainFunc = testModeMainFunc
 	ann.AnnotateAstFile(astFile)
+	if err := ann.condsErr(); err != nil {
+		return nil, err
+	}
 
 	// n debug stmts inserted
 	afd.NMsgIndexes = debug.AfdMsgIndex(ann.debugNIndexes)
 
+	if len(ann.debugMaxHits) > 0 {
+		annset.maxHits.Lock()
+		annset.maxHits.m[afd.FileIndex] = ann.debugMaxHits
+		annset.maxHits.Unlock()
+	}
+
 	return ann, nil
 }
 
@@ -105,7 +122,7 @@ func (annset *AnnotatorSet) annotatorFileData(filename string) (*debug.Annotator
 		FileIndex: uint16(annset.afds.index),
 		FileSize:  uint32(len(src)),
 		Filename:  filename,
-		FileHash:  sourceHash(src),
+		FileHash:  SourceHash(src),
 	}
 	annset.afds.m[filename] = afd
 
@@ -132,6 +149,31 @@ func (annset *AnnotatorSet) buildConfigAfdEntries() string {
 	return strings.Join(u, ",")
 }
 
+// Consecutive debug indexes with the same max are joined in one entry.
+func (annset *AnnotatorSet) buildConfigMaxHitsEntries() string {
+	annset.maxHits.Lock()
+	defer annset.maxHits.Unlock()
+	u := []string{}
+	for _, afd := range annset.afds.order {
+		m := annset.maxHits.m[afd.FileIndex]
+		dis := []int{}
+		for di := range m {
+			dis = append(dis, di)
+		}
+		sort.Ints(dis)
+		for i := 0; i < len(dis); {
+			j := i + 1
+			for j < len(dis) && dis[j] == dis[j-1]+1 && m[dis[j]] == m[dis[i]] {
+				j++
+			}
+			s := fmt.Sprintf("{%v,%v,%v,%v}", afd.FileIndex, dis[i], dis[j-1]+1, m[dis[i]])
+			u = append(u, s)
+			i = j
+		}
+	}
+	return strings.Join(u, ",")
+}
+
 //----------
 //----------
 //----------
@@ -157,7 +199,7 @@ func newAnnSetDebugOpt() *AnnSetDebugOpt {
 //----------
 //----------
 
-func sourceHash(b []byte) []byte {
+func SourceHash(b []byte) []byte {
 	h := sha1.New()
 	h.Write(b)
 	return h.Sum(nil)
@@ -52,6 +52,9 @@ type Cmd struct {
 	Stdout io.Writer
 	Stderr io.Writer
 
+	// editor side: initial breakpoints, called on each connection
+	StartBreakpoints func(*debug.FilesDataMsg) []*debug.Breakpoint
+
 	tmpDir            string
 	tmpBuiltFile      string // godebug file built
 	tmpGoModFilename  string
@@ -122,7 +125,7 @@ func (cmd *Cmd) Start(ctx context.Context, args []string) (bool, error) {
 	if err := cmd.start2(ctx, args); err != nil {
 		return true, err
 	}
-	if cmd.flags.mode.build {
+	if cmd.flags.mode.build || cmd.flags.mode.prepare {
 		return true, nil
 	}
 	return false, nil
@@ -193,6 +196,8 @@ func (cmd *Cmd) start2(ctx context.Context, args []string) error {
 		// inform the address used in the binary
 		cmd.printBuildInfo()
 		return nil
+	case m.prepare:
+		return cmd.prepare(ctx)
 	case m.run || m.test:
 		return cmd.start3(ctx)
 	case m.connect:
@@ -246,6 +251,11 @@ func (cmd *Cmd) startEditorSide(ctx context.Context) error {
 	}
 
 	peds := &debug.ProtoEditorSide{}
+	if cmd.StartBreakpoints != nil {
+		peds.FillStartMsg = func(fd *debug.FilesDataMsg, rsm *debug.ReqStartMsg) {
+			rsm.Breakpoints = cmd.StartBreakpoints(fd)
+		}
+	}
 	//peds.Logger = debug.Logger{"peds: ", logw} // DEBUG: lots of output
 
 	p, err := debug.NewProto(ctx, addr, peds, cmd.flags.editorIsServer, cmd.flags.continueServing, logw)
@@ -309,9 +319,30 @@ func (cmd *Cmd) ProtoRead() (any, error) {
 	return v, err
 }
 
+// Editor side: ex: continue msg, breakpoints msg.
+func (cmd *Cmd) ProtoWrite(v any) error {
+	return cmd.start.proto.Write(v)
+}
+
 //----------
 
 func (cmd *Cmd) build(ctx context.Context) error {
+	if err := cmd.prepareFiles(ctx); err != nil {
+		return err
+	}
+
+	// DEBUG
+	//cmd.printAnnotatedFilesAsts(cmd.fa)
+
+	if err := cmd.build2(ctx); err != nil {
+		// auto-set work flag to avoid cleanup; allows clicking on failing work files locations
+		cmd.flags.work = true
+
+		return err
+	}
+	return nil
+}
+func (cmd *Cmd) prepareFiles(ctx context.Context) error {
 	if err := cmd.fa.find(ctx); err != nil {
 		return err
 	}
@@ -327,20 +358,7 @@ func (cmd *Cmd) build(ctx context.Context) error {
 	if err := cmd.buildAlternativeGoMod(ctx); err != nil {
 		return err
 	}
-	if err := cmd.buildOverlayFile(ctx); err != nil {
-		return err
-	}
-
-	// DEBUG
-	//cmd.printAnnotatedFilesAsts(cmd.fa)
-
-	if err := cmd.build2(ctx); err != nil {
-		// auto-set work flag to avoid cleanup; allows clicking on failing work files locations
-		cmd.flags.work = true
-
-		return err
-	}
-	return nil
+	return cmd.buildOverlayFile(ctx)
 }
 func (cmd *Cmd) build2(ctx context.Context) error {
 	outFilename, err := cmd.buildOutFilename(cmd.fa)
@@ -376,6 +394,44 @@ func (cmd *Cmd) build2(ctx context.Context) error {
 
 //------------
 
+// Generates the godebug files into the work dir (kept), and prints the flags to be used by an external build system (ex: makefile, bazel). The built binary connects like the one from the "build" mode (use "GoDebug connect").
+func (cmd *Cmd) prepare(ctx context.Context) error {
+	if err := cmd.prepareFiles(ctx); err != nil {
+		return err
+	}
+
+	a := []string{}
+	if cmd.alternativeGoMod != "" {
+		a = append(a, "-modfile="+cmd.alternativeGoMod)
+	}
+	a = append(a, "-overlay="+cmd.overlayFilename)
+	a = append(a, cmd.buildArgs()...)
+	flags := joinQuoted(a)
+
+	info := fmt.Sprintf("network=%v, addr=%v, editorIsServer=%v", cmd.flags.network, cmd.flags.address, cmd.flags.editorIsServer)
+	cmd.printf("prepare: %v (builtin: %s)\n", cmd.tmpDir, info)
+	cmd.printf("build flags (ex: GOFLAGS=%q, flags given in the cmd line take precedence):\n", flags)
+	fmt.Fprintf(cmd.Stdout, "%s\n", flags)
+
+	// env vars set by the generated files
+	env := []string{}
+	if gw := osutil.GetEnv(cmd.env, "GOWORK"); strings.HasPrefix(gw, cmd.tmpDir) {
+		env = append(env, "GOWORK="+quoteArg(gw))
+	}
+	if cmd.gopathMode {
+		env = append(env, "GOPATH="+quoteArg(osutil.GetEnv(cmd.env, "GOPATH")))
+	}
+	if len(env) > 0 {
+		cmd.printf("env (needed with the build flags):\n")
+		for _, e := range env {
+			fmt.Fprintf(cmd.Stdout, "%s\n", e)
+		}
+	}
+	return nil
+}
+
+//------------
+
 // DEBUG
 func (cmd *Cmd) printAnnotatedFilesAsts(fa *FilesToAnnotate) {
 	for orig := range cmd.overlay {
@@ -562,6 +618,18 @@ func (cmd *Cmd) editorRootTmpDir() string {
 }
 
 func (cmd *Cmd) setupTmpDir() error {
+	if cmd.flags.workDir != "" {
+		dir := cmd.flags.workDir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(cmd.Dir, dir)
+		}
+		if err := iout.MkdirAll(dir); err != nil {
+			return err
+		}
+		cmd.tmpDir = dir
+		return nil
+	}
+
 	fixedDir := cmd.editorRootTmpDir()
 	dir, err := ioutil.TempDir(fixedDir, "work*")
 	if err != nil {
@@ -679,7 +747,7 @@ func (cmd *Cmd) annotateFiles2(ctx context.Context) error {
 		if ok {
 			ti = pkg.TypesInfo
 		}
-		ann, err := cmd.annset.AnnotateAstFile(astFile, ti, cmd.fa.nodeAnnTypes, cmd.flags.mode.test)
+		ann, err := cmd.annset.AnnotateAstFile(astFile, ti, cmd.fa.nodeAnnTypes, cmd.fa.breakNodes, cmd.fa.limitNodes, cmd.flags.mode.test)
 		if err != nil {
 			return err
 		}
@@ -832,6 +900,7 @@ func (cmd *Cmd) buildDebugPkg(ctx context.Context) error {
 func (cmd *Cmd) buildConfigSrc() []byte {
 	fl := &cmd.flags
 	bcce := cmd.annset.buildConfigAfdEntries()
+	bcmh := cmd.annset.buildConfigMaxHitsEntries()
 
 	fb := strconv.FormatBool
 
@@ -846,7 +915,12 @@ func init(){
 	exso.srcLines = ` + fb(fl.srcLines) + `
 	exso.syncSend = ` + fb(fl.syncSend) + `
 	exso.stringifyBytesRunes = ` + fb(fl.stringifyBytesRunes) + `
+	exso.strMax = ` + strconv.Itoa(fl.strMax) + `
+	exso.strDepth = ` + strconv.Itoa(fl.strDepth) + `
+	exso.maxMsgs = ` + strconv.Itoa(fl.maxMsgs) + `
+	exso.ringMsgs = ` + fb(fl.ringMsgs) + `
 	exso.filesData = []*AnnotatorFileData{` + bcce + `}
+	exso.maxHits = []*MaxHits{` + bcmh + `}
 }
 `
 	return []byte(src)
@@ -868,6 +942,10 @@ func (cmd *Cmd) buildAlternativeGoWork(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("unable to read go work file: %w", err)
 	}
+	if cmd.flags.mode.prepare {
+		return cmd.buildAlternativeGoWorkInTmpDir(gwFilename, src)
+	}
+
 	// add use line to debug pkg
 	line := fmt.Sprintf("\nuse %s\n", cmd.debugPkgDir)
 	src = append(src, []byte(line)...)
@@ -890,6 +968,45 @@ func (cmd *Cmd) buildAlternativeGoWork(ctx context.Context) error {
 	return nil
 }
 
+// prepare mode: the go.work needs to exist after exit, so it can't be created in the src dir (removed on cleanup); relative paths are made absolute since it will be in another dir.
+func (cmd *Cmd) buildAlternativeGoWorkInTmpDir(gwFilename string, src []byte) error {
+	wf, err := modfile.ParseWork(gwFilename, src, nil)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(gwFilename)
+	absPath := func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	uses := []*modfile.Use{}
+	for _, u := range wf.Use {
+		uses = append(uses, &modfile.Use{Path: absPath(u.Path), ModulePath: u.ModulePath})
+	}
+	uses = append(uses, &modfile.Use{Path: cmd.debugPkgDir})
+	wf.SetUse(uses)
+	for _, r := range wf.Replace {
+		if r.New.Version == "" && modfile.IsDirectoryPath(r.New.Path) { // local dir
+			if err := wf.AddReplace(r.Old.Path, r.Old.Version, absPath(r.New.Path), ""); err != nil {
+				return err
+			}
+		}
+	}
+	wf.Cleanup()
+
+	gw2Filename := filepath.Join(cmd.tmpDir, "godebug_go.work")
+	if err := mkdirAllWriteFile(gw2Filename, modfile.Format(wf.Syntax)); err != nil {
+		return err
+	}
+	// copy go.work.sum (best effort)
+	_ = copyFile(gwFilename+".sum", gw2Filename+".sum")
+	cmd.logf("goWorkFilename: %v\n", gw2Filename)
+	cmd.env = osutil.AppendEnv(cmd.env, []string{"GOWORK=" + gw2Filename})
+	return nil
+}
+
 //------------
 
 func (cmd *Cmd) buildAlternativeGoMod(ctx context.Context) error {
@@ -901,6 +1018,9 @@ func (cmd *Cmd) buildAlternativeGoMod(ctx context.Context) error {
 	}
 
 	filename, ok := goutil.FindGoMod(cmd.Dir)
+	if !ok && cmd.flags.mode.prepare {
+		return fmt.Errorf("prepare: missing go.mod (the temporary go.mod would be removed on exit)")
+	}
 	if !ok {
 		// in the case of a simple main.go without any go.mod (but in modules mode), it needs to create an artificial go.mod in order to reference the debug pkg that is located in the tmp dir
 
@@ -1085,6 +1205,29 @@ func (cmd *Cmd) printBuildInfo() {
 //------------
 //------------
 
+// Joins the args with spaces, quoting the ones with spaces or quotes. The result can be used in GOFLAGS (go accepts single or double quoted elements) and in a shell cmd line.
+func joinQuoted(args []string) string {
+	u := []string{}
+	for _, a := range args {
+		u = append(u, quoteArg(a))
+	}
+	return strings.Join(u, " ")
+}
+
+func quoteArg(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if !strings.ContainsAny(s, " \t\n'\"") {
+		return s
+	}
+	// go doesn't support escapes inside quotes: use the quote not present
+	if strings.Contains(s, "'") {
+		return `"` + s + `"`
+	}
+	return "'" + s + "'"
+}
+
 func writeFile(filename string, src []byte) error {
 	return os.WriteFile(filename, src, 0640)
 }
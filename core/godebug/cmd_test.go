@@ -37,6 +37,11 @@ func godebugTester(st *testutil.ST, args []string) error {
 
 	args = args[1:] // clear "godebugtester"
 
+	// expand env vars (ex: "-addr=$WORK/a.sock")
+	for i, a := range args {
+		args[i] = os.Expand(a, st.Env.Get)
+	}
+
 	cmd := NewCmd()
 	cmd.Testing = true
 
@@ -84,10 +89,18 @@ func godebugTester(st *testutil.ST, args []string) error {
 			//}
 		case *debug.OffsetMsg:
 			pr(StringifyItem(t.Item))
+			if err := continueOnBreak(cmd, t); err != nil {
+				return err
+			}
 		case *debug.OffsetMsgs:
 			for _, m := range *t {
 				pr(StringifyItem(m.Item))
+				if err := continueOnBreak(cmd, m); err != nil {
+					return err
+				}
 			}
+		case *debug.TestMsg:
+			pr(fmt.Sprintf("test: %v: done=%v failed=%v", t.Name, t.Done, t.Failed))
 		default:
 			return fmt.Errorf("unexpected type: %T, %v", v, v)
 		}
@@ -104,6 +117,13 @@ func godebugTester(st *testutil.ST, args []string) error {
 	return cmd.Wait()
 }
 
+func continueOnBreak(cmd *Cmd, m *debug.OffsetMsg) error {
+	if _, ok := m.Item.(*debug.ItemBreak); ok {
+		return cmd.ProtoWrite(&debug.ContinueMsg{GoId: m.GoId})
+	}
+	return nil
+}
+
 //----------
 //----------
 //----------
@@ -278,6 +298,16 @@ func runClient(t *testing.T, ctx context.Context, addr debug.Addr, isServer bool
 	}
 }
 
+//----------
+
+func TestJoinQuoted(t *testing.T) {
+	args := []string{"-overlay=/a b/c.json", "-tags=t1", "-o=it's", ""}
+	s := joinQuoted(args)
+	if s != `'-overlay=/a b/c.json' -tags=t1 "-o=it's" ''` {
+		t.Fatal(s)
+	}
+}
+
 //----------
 //----------
 //----------
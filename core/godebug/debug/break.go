@@ -0,0 +1,165 @@
+package debug
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// exec side breakpoints: goroutines pause until the editor sends a continue msg for that goroutine
+type execBreaks struct {
+	active atomic.Bool // fast check at each msg: have breakpoints or stepping
+
+	mu struct {
+		sync.Mutex
+		*sync.Cond
+
+		bps    map[AfdFileIndex][]*Breakpoint
+		lastBp map[GoroutineId]*Breakpoint // avoid pausing at every msg of the same breakpoint
+		paused map[GoroutineId]bool        // waiting for a continue msg
+		steps  map[GoroutineId]bool        // pause at the next msg
+		run    map[GoroutineId][]func()    // funcs to run by the paused goroutine (ex: stringify its values)
+		closed bool                        // no more continue msgs will arrive, don't pause
+	}
+}
+
+func newExecBreaks() *execBreaks {
+	b := &execBreaks{}
+	b.mu.Cond = sync.NewCond(&b.mu)
+	b.mu.bps = map[AfdFileIndex][]*Breakpoint{}
+	b.mu.lastBp = map[GoroutineId]*Breakpoint{}
+	b.mu.paused = map[GoroutineId]bool{}
+	b.mu.steps = map[GoroutineId]bool{}
+	b.mu.run = map[GoroutineId][]func(){}
+	return b
+}
+
+//----------
+
+func (b *execBreaks) setBreakpoints(bps []*Breakpoint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.mu.bps = map[AfdFileIndex][]*Breakpoint{}
+	b.mu.lastBp = map[GoroutineId]*Breakpoint{}
+	for _, bp := range bps {
+		b.mu.bps[bp.FileIndex] = append(b.mu.bps[bp.FileIndex], bp)
+	}
+	b.updateActive()
+}
+
+// Resumes the goroutine of the msg (all paused goroutines if the id is zero).
+func (b *execBreaks) cont(cm *ContinueMsg) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer b.mu.Broadcast()
+	if cm.GoId == 0 {
+		b.mu.paused = map[GoroutineId]bool{}
+	} else {
+		delete(b.mu.paused, cm.GoId)
+		if cm.Step {
+			b.mu.steps[cm.GoId] = true
+		}
+	}
+	b.updateActive()
+}
+
+// Runs fn in the goroutine if it is paused; returns false otherwise.
+func (b *execBreaks) runPaused(id GoroutineId, fn func()) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.mu.paused[id] || b.mu.closed {
+		return false
+	}
+	b.mu.run[id] = append(b.mu.run[id], fn)
+	b.mu.Broadcast()
+	return true
+}
+
+func (b *execBreaks) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer b.mu.Broadcast()
+	b.mu.closed = true
+	b.updateActive()
+}
+
+// Must have the lock.
+func (b *execBreaks) updateActive() {
+	v := !b.mu.closed && (len(b.mu.steps) > 0 || len(b.mu.bps) > 0)
+	b.active.Store(v)
+}
+
+//----------
+
+// Pauses if the msg is at a breakpoint or stepping.
+func (b *execBreaks) check(m *OffsetMsg) {
+	b.mu.Lock()
+	hit := b.mu.steps[m.GoId]
+	if bp, ok := b.breakpoint(m); ok {
+		if b.mu.lastBp[m.GoId] != bp {
+			b.mu.lastBp[m.GoId] = bp
+			hit = true
+		}
+	} else {
+		delete(b.mu.lastBp, m.GoId)
+	}
+	b.mu.Unlock()
+
+	if hit {
+		b.pause(m)
+	}
+}
+
+// Must have the lock.
+func (b *execBreaks) breakpoint(m *OffsetMsg) (*Breakpoint, bool) {
+	for _, bp := range b.mu.bps[m.FileIndex] {
+		if m.Offset >= bp.Start && m.Offset < bp.End {
+			return bp, true
+		}
+	}
+	return nil, false
+}
+
+// Notifies the editor with a break msg (same path as the other msgs to keep the order) and waits for a continue msg for this goroutine.
+func (b *execBreaks) pause(m *OffsetMsg) {
+	// set as paused before notifying, the continue msg can arrive before waiting
+	b.mu.Lock()
+	if b.mu.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.mu.paused[m.GoId] = true
+	delete(b.mu.steps, m.GoId)
+	b.updateActive()
+	b.mu.Unlock()
+
+	bm := &OffsetMsg{
+		FileIndex: m.FileIndex,
+		MsgIndex:  m.MsgIndex,
+		Offset:    m.Offset,
+		GoId:      m.GoId,
+		Item:      &ItemBreak{},
+	}
+	err := exs.p.WriteMsg(bm)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		delete(b.mu.paused, m.GoId)
+		return
+	}
+	for {
+		if fns := b.mu.run[m.GoId]; len(fns) > 0 {
+			delete(b.mu.run, m.GoId)
+			b.mu.Unlock()
+			for _, fn := range fns {
+				fn()
+			}
+			b.mu.Lock()
+			continue
+		}
+		if !b.mu.paused[m.GoId] || b.mu.closed {
+			return
+		}
+		b.mu.Wait()
+	}
+}
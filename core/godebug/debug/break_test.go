@@ -0,0 +1,130 @@
+package debug
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExecBreaks1(t *testing.T) {
+	tp := &testBreakProto{ch: make(chan *OffsetMsg, 10)}
+	p0 := exs.p
+	exs.p = tp
+	defer func() { exs.p = p0 }()
+
+	b := newExecBreaks()
+	b.setBreakpoints([]*Breakpoint{{FileIndex: 1, Start: 10, End: 20}})
+	if !b.active.Load() {
+		t.Fatal("not active")
+	}
+
+	msg := func(offset int) *OffsetMsg {
+		return &OffsetMsg{FileIndex: 1, Offset: AfdFileSize(offset), GoId: 7}
+	}
+	run := func(offsets ...int) *sync.WaitGroup {
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, o := range offsets {
+				b.check(msg(o))
+			}
+		}()
+		return wg
+	}
+	waitBreak := func(offset int) {
+		t.Helper()
+		select {
+		case m := <-tp.ch:
+			if m.Offset != AfdFileSize(offset) || m.GoId != 7 {
+				t.Fatalf("unexpected break: %v", m)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for break")
+		}
+	}
+
+	// pauses once at the breakpoint (2nd msg in the same breakpoint doesn't pause)
+	wg := run(5, 12, 15, 25)
+	waitBreak(12)
+	b.cont(&ContinueMsg{})
+	wg.Wait()
+	if len(tp.ch) != 0 {
+		t.Fatal("unexpected break")
+	}
+
+	// step: pauses again at the next msg of the same goroutine
+	wg = run(12, 30, 31)
+	waitBreak(12)
+	b.cont(&ContinueMsg{Step: true, GoId: 7})
+	waitBreak(30)
+	b.cont(&ContinueMsg{})
+	wg.Wait()
+
+	// closed: doesn't pause
+	b.close()
+	if b.active.Load() {
+		t.Fatal("active after close")
+	}
+}
+
+func TestExecBreaks2(t *testing.T) {
+	tp := &testBreakProto{ch: make(chan *OffsetMsg, 10)}
+	p0 := exs.p
+	exs.p = tp
+	defer func() { exs.p = p0 }()
+
+	b := newExecBreaks()
+	b.setBreakpoints([]*Breakpoint{{FileIndex: 1, Start: 10, End: 20}})
+
+	// two goroutines paused at the same breakpoint
+	done := map[GoroutineId]chan bool{}
+	for _, id := range []GoroutineId{7, 8} {
+		ch := make(chan bool)
+		done[id] = ch
+		go func() {
+			b.check(&OffsetMsg{FileIndex: 1, Offset: 12, GoId: id})
+			close(ch)
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-tp.ch:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for break")
+		}
+	}
+
+	// continuing one goroutine doesn't resume the other
+	b.cont(&ContinueMsg{GoId: 8})
+	select {
+	case <-done[8]:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for goroutine 8")
+	}
+	select {
+	case <-done[7]:
+		t.Fatal("goroutine 7 resumed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	b.cont(&ContinueMsg{GoId: 7})
+	select {
+	case <-done[7]:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for goroutine 7")
+	}
+}
+
+//----------
+
+type testBreakProto struct {
+	Proto
+	ch chan *OffsetMsg
+}
+
+func (p *testBreakProto) WriteMsg(m *OffsetMsg) error {
+	if _, ok := m.Item.(*ItemBreak); ok {
+		p.ch <- m
+	}
+	return nil
+}
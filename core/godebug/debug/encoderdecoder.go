@@ -97,6 +97,14 @@ func decode(r io.Reader, v any, logger Logger) error {
 
 }
 
+// Editor side: same format as the protocol (ex: saving the received msgs to a file).
+func Encode(w io.Writer, v any) error {
+	return encode(w, v, Logger{})
+}
+func Decode(r io.Reader, v any) error {
+	return decode(r, v, Logger{})
+}
+
 //----------
 //----------
 //----------
@@ -37,6 +37,7 @@ func TestEncode2(t *testing.T) {
 		0 0 // file index
 		0 0 0 0 // debug index
 		0 0 0 0 // offset
+		0 0 0 0 0 0 0 0 // goroutine id
 		15 // itemvalue id (interface)
 		15 // itemvalue id (pointer)
 		0 1 // str len
@@ -50,7 +51,7 @@ func TestEncode2(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	b2 := "[3 7 16 0 0 0 0 0 0 0 0 0 0 17 17 0 1 49]"
+	b2 := "[3 7 16 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 17 17 0 1 49]"
 	b3 := fmt.Sprintf("%v", b)
 	if b2 != b3 {
 		t.Fatalf("expecting:\n%v got\n%v", b2, b3)
@@ -70,6 +71,20 @@ func TestEncode3(t *testing.T) {
 		t.Fatal(s)
 	}
 }
+func TestEncode4(t *testing.T) {
+	lm := &OffsetMsg{Item: IVi(1), FileIndex: 2, GoId: 1<<40 + 3}
+	v, _, err := testEncDec(t, lm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lm2, ok := v.(*OffsetMsg)
+	if !ok {
+		t.Fatal(v)
+	}
+	if lm2.GoId != lm.GoId || lm2.FileIndex != lm.FileIndex {
+		t.Fatalf("got %v", lm2)
+	}
+}
 
 //----------
 //----------
@@ -0,0 +1,161 @@
+package debug
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Max hits for a range of debug indexes (set by generated config).
+type MaxHits struct {
+	FileIndex  AfdFileIndex
+	Start, End AfdMsgIndex
+	Max        int
+}
+
+//----------
+
+// exec side msgs limits: drop msgs after the max hits, or keep the last ones in ring buffers (sent on close)
+type execLimits struct {
+	active atomic.Bool // fast check at each msg, only set at init (read by the program goroutines)
+	ring   bool
+	max    [][]int32        // [fileIndex][debugIndex], zero: no limit
+	hits   [][]atomic.Int32 // [fileIndex][debugIndex]
+
+	rings struct {
+		sync.Mutex
+		m   map[ringKey]*msgsRing
+		seq uint64 // arrival order across rings
+	}
+}
+
+func newExecLimits() *execLimits {
+	lim := &execLimits{}
+	lim.rings.m = map[ringKey]*msgsRing{}
+	return lim
+}
+
+// Not safe to call concurrently with accept/keep.
+func (lim *execLimits) setup(afds []*AnnotatorFileData, maxMsgs int, mhs []*MaxHits, ring bool) {
+	active := maxMsgs > 0 || len(mhs) > 0
+	defer lim.active.Store(active) // after the setup
+	if !active {
+		return
+	}
+	lim.ring = ring
+	lim.max = make([][]int32, len(afds))
+	lim.hits = make([][]atomic.Int32, len(afds))
+	for _, afd := range afds {
+		if int(afd.FileIndex) >= len(afds) {
+			continue
+		}
+		u := make([]int32, afd.NMsgIndexes)
+		for i := range u {
+			u[i] = int32(maxMsgs)
+		}
+		lim.max[afd.FileIndex] = u
+		lim.hits[afd.FileIndex] = make([]atomic.Int32, afd.NMsgIndexes)
+	}
+	for _, mh := range mhs {
+		if int(mh.FileIndex) >= len(lim.max) {
+			continue
+		}
+		u := lim.max[mh.FileIndex]
+		for i := mh.Start; i < mh.End && int(i) < len(u); i++ {
+			u[i] = int32(mh.Max)
+		}
+	}
+}
+
+func (lim *execLimits) maxAt(fileIndex, debugIndex int) int32 {
+	if fileIndex >= len(lim.max) || debugIndex >= len(lim.max[fileIndex]) {
+		return 0
+	}
+	return lim.max[fileIndex][debugIndex]
+}
+
+//----------
+
+// False if the msg should be dropped (max hits reached).
+func (lim *execLimits) accept(fileIndex, debugIndex int) bool {
+	if lim.ring {
+		return true
+	}
+	max := lim.maxAt(fileIndex, debugIndex)
+	if max <= 0 {
+		return true
+	}
+	h := &lim.hits[fileIndex][debugIndex]
+	if h.Load() >= max { // avoids overflow on long runs
+		return false
+	}
+	return h.Add(1) <= max
+}
+
+// True if the msg was kept in a ring buffer (to be sent later).
+func (lim *execLimits) keep(m *OffsetMsg) bool {
+	if !lim.ring {
+		return false
+	}
+	max := lim.maxAt(int(m.FileIndex), int(m.MsgIndex))
+	if max <= 0 {
+		return false
+	}
+	lim.rings.Lock()
+	defer lim.rings.Unlock()
+	k := ringKey{m.FileIndex, m.MsgIndex}
+	r, ok := lim.rings.m[k]
+	if !ok {
+		r = &msgsRing{max: int(max)}
+		lim.rings.m[k] = r
+	}
+	r.add(&ringEntry{lim.rings.seq, m})
+	lim.rings.seq++
+	return true
+}
+
+// Returns the kept msgs in arrival order, and clears the rings.
+func (lim *execLimits) takeKept() []*OffsetMsg {
+	lim.rings.Lock()
+	defer lim.rings.Unlock()
+	w := []*ringEntry{}
+	for _, r := range lim.rings.m {
+		w = append(w, r.entries...)
+	}
+	lim.rings.m = map[ringKey]*msgsRing{}
+	sort.Slice(w, func(a, b int) bool {
+		return w[a].seq < w[b].seq
+	})
+	res := make([]*OffsetMsg, 0, len(w))
+	for _, e := range w {
+		res = append(res, e.m)
+	}
+	return res
+}
+
+//----------
+
+type ringKey struct {
+	fileIndex AfdFileIndex
+	msgIndex  AfdMsgIndex
+}
+
+type ringEntry struct {
+	seq uint64
+	m   *OffsetMsg
+}
+
+type msgsRing struct {
+	max     int
+	entries []*ringEntry
+	next    int // index to overwrite when full
+}
+
+func (r *msgsRing) add(e *ringEntry) {
+	if len(r.entries) < r.max {
+		r.entries = append(r.entries, e)
+		return
+	}
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % r.max
+}
@@ -0,0 +1,57 @@
+package debug
+
+import (
+	"testing"
+)
+
+func TestExecLimits1(t *testing.T) {
+	afds := []*AnnotatorFileData{{FileIndex: 0, NMsgIndexes: 3}}
+	mhs := []*MaxHits{{FileIndex: 0, Start: 1, End: 2, Max: 1}}
+	lim := newExecLimits()
+	lim.setup(afds, 2, mhs, false)
+	if !lim.active.Load() {
+		t.Fatal("not active")
+	}
+
+	n := [3]int{}
+	for i := 0; i < 5; i++ {
+		for di := 0; di < 3; di++ {
+			if lim.accept(0, di) {
+				n[di]++
+			}
+		}
+	}
+	if n != [3]int{2, 1, 2} {
+		t.Fatal(n)
+	}
+}
+
+func TestExecLimits2(t *testing.T) {
+	afds := []*AnnotatorFileData{{FileIndex: 0, NMsgIndexes: 2}}
+	mhs := []*MaxHits{{FileIndex: 0, Start: 1, End: 2, Max: 3}}
+	lim := newExecLimits()
+	lim.setup(afds, 0, mhs, true)
+
+	// index 0 has no limit, not kept
+	if lim.keep(&OffsetMsg{MsgIndex: 0}) {
+		t.Fatal("kept")
+	}
+	for i := 0; i < 10; i++ {
+		m := &OffsetMsg{MsgIndex: 1, Offset: AfdFileSize(i)}
+		if !lim.keep(m) {
+			t.Fatal("not kept")
+		}
+	}
+	msgs := lim.takeKept()
+	if len(msgs) != 3 {
+		t.Fatal(len(msgs))
+	}
+	for i, m := range msgs {
+		if m.Offset != AfdFileSize(7+i) {
+			t.Fatal(i, m.Offset)
+		}
+	}
+	if len(lim.takeKept()) != 0 {
+		t.Fatal("not cleared")
+	}
+}
@@ -549,6 +549,8 @@ type ProtoEditorSide struct {
 	FData   *FilesDataMsg // received from exec side
 	fdataMu sync.Mutex
 
+	FillStartMsg func(*FilesDataMsg, *ReqStartMsg) // ex: initial breakpoints
+
 	Logger
 }
 
@@ -571,7 +573,11 @@ func (eds *ProtoEditorSide) initProto(conn Conn) (*ProtoConn, error) {
 		return nil, fmt.Errorf("protoeditorside: fdata is nil")
 	}
 
-	if err := pconn.Write(&ReqStartMsg{}); err != nil {
+	rsm := &ReqStartMsg{}
+	if eds.FillStartMsg != nil {
+		eds.FillStartMsg(eds.FData, rsm)
+	}
+	if err := pconn.Write(rsm); err != nil {
 		return nil, err
 	}
 	return pconn, nil
@@ -619,6 +625,7 @@ type ProtoExecSide struct {
 	pconn            *ProtoConn
 	FData            *FilesDataMsg // to be sent, can be discarded
 	NoWriteBuffering bool
+	OnStart          func(*ReqStartMsg)
 
 	Logger
 }
@@ -647,9 +654,13 @@ func (exs *ProtoExecSide) initProto(conn Conn) (*ProtoConn, error) {
 		return nil, err
 	}
 
-	if err := pconn.Read(&ReqStartMsg{}); err != nil {
+	rsm := &ReqStartMsg{}
+	if err := pconn.Read(rsm); err != nil {
 		return nil, err
 	}
+	if exs.OnStart != nil {
+		exs.OnStart(rsm)
+	}
 	return pconn, nil
 }
 
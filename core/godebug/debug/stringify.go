@@ -10,9 +10,21 @@ func stringify(v any) string {
 	return stringifyV3(v)
 }
 func stringifyV3(v any) string {
-	p := newPrint3(150, 7, exso.stringifyBytesRunes)
+	s, _ := stringifyV3b(v, exso.strMax, exso.strDepth)
+	return s
+}
+
+// Returns true if the value was cut (max length or depth reached). Zero max/depth uses the defaults.
+func stringifyV3b(v any, max, maxDepth int) (string, bool) {
+	if max <= 0 {
+		max = 150
+	}
+	if maxDepth <= 0 {
+		maxDepth = 7
+	}
+	p := newPrint3(max, maxDepth, exso.stringifyBytesRunes)
 	p.do(v)
-	return p.ToString()
+	return p.ToString(), p.cut
 }
 
 //----------
@@ -26,6 +38,7 @@ type print3 struct {
 	avail    int
 	maxDepth int
 	stk      []reflect.Value
+	cut      bool // printed "..."
 
 	stringifyBytesAndRunes bool
 }
@@ -205,7 +218,7 @@ func (p *print3) printSliceOrArrayAsString(v reflect.Value) bool {
 
 		for i := 0; i < v.Len(); i++ {
 			if p.avail <= 0 {
-				p.print("...")
+				p.printCutMark()
 				break
 			}
 			u := uint8(v.Index(i).Uint())
@@ -223,7 +236,7 @@ func (p *print3) printSliceOrArrayAsString(v reflect.Value) bool {
 
 		for i := 0; i < v.Len(); i++ {
 			if p.avail <= 0 {
-				p.print("...")
+				p.printCutMark()
 				break
 			}
 			u := int32(v.Index(i).Int())
@@ -239,14 +252,14 @@ func (p *print3) printSliceOrArrayAsString(v reflect.Value) bool {
 
 func (p *print3) printLoopSep(i int, depth int) bool {
 	if depth >= p.maxDepth {
-		p.print("...")
+		p.printCutMark()
 		return false
 	}
 	if i > 0 {
 		p.print(" ")
 	}
 	if p.avail <= 0 {
-		p.print("...")
+		p.printCutMark()
 		return false
 	}
 	return true
@@ -257,7 +270,7 @@ func (p *print3) printLoopSep(i int, depth int) bool {
 func (p *print3) printCut(s string) {
 	if len(s) > p.avail {
 		p.print(s[:p.avail])
-		p.print("...")
+		p.printCutMark()
 		return
 	}
 	p.print(s)
@@ -265,7 +278,7 @@ func (p *print3) printCut(s string) {
 func (p *print3) printBytesCut(b []byte) {
 	if len(b) > p.avail {
 		p.printBytes(b[:p.avail])
-		p.print("...")
+		p.printCutMark()
 		return
 	}
 	p.printBytes(b)
@@ -287,6 +300,10 @@ func (p *print3) printBytes(b []byte) {
 	}
 	p.avail -= n
 }
+func (p *print3) printCutMark() {
+	p.cut = true
+	p.print("...")
+}
 func (p *print3) canPrint() bool {
 	return p.avail >= 0
 }
@@ -298,6 +298,21 @@ func runTest3(t *testing.T, v any, out string, max, maxDepth int, sbr bool) {
 	}
 }
 
+func TestIVi2(t *testing.T) {
+	// zero limits use the cmd line limits
+	max0, depth0 := exso.strMax, exso.strDepth
+	defer func() { exso.strMax, exso.strDepth = max0, depth0 }()
+	exso.strMax, exso.strDepth = 6, 0
+
+	v := []int{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	if s := IVi2(v, 0, 3).(*ItemValue).Str; s != "[1 2 3 ...]" {
+		t.Fatal(s)
+	}
+	if s := IVi2(v, 8, 0).(*ItemValue).Str; s != "[1 2 3 4 ...]" {
+		t.Fatal(s)
+	}
+}
+
 //----------
 
 type Dummy1 struct{ s string }
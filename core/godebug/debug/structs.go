@@ -41,6 +41,16 @@ func registerStructsForProtoConn() {
 	reg(&ItemAnon{})
 	reg(&ItemLabel{})
 	reg(&ItemNotAnn{})
+
+	// appended to keep previous ids
+	reg(&ItemBreak{})
+	reg(&ContinueMsg{})
+	reg(&BreakpointsMsg{})
+	reg(&Breakpoint{})
+	reg(&ItemValueRef{})
+	reg(&ReqStringifyMsg{})
+	reg(&StringifyMsg{})
+	reg(&TestMsg{})
 }
 
 //----------
@@ -49,7 +59,50 @@ func registerStructsForProtoConn() {
 
 type HandshakeMsg struct{ Msg string }
 type ReqFilesDataMsg struct{}
-type ReqStartMsg struct{}
+type ReqStartMsg struct {
+	Breakpoints []*Breakpoint // initial breakpoints
+}
+
+//----------
+
+// Sent by the editor to resume the paused goroutines. With step, the goroutine pauses again at its next msg.
+type ContinueMsg struct {
+	Step bool
+	GoId GoroutineId // goroutine to resume (zero: all paused goroutines)
+}
+
+// Sent by the editor to replace the current breakpoints.
+type BreakpointsMsg struct {
+	Breakpoints []*Breakpoint
+}
+
+// Pauses at msgs with an offset in [Start,End) (ex: a line).
+type Breakpoint struct {
+	FileIndex  AfdFileIndex
+	Start, End AfdFileSize
+}
+
+// Sent by the editor to request the bigger value of a value that was cut (see ItemValueRef). Only answered while the goroutine is paused.
+type ReqStringifyMsg struct {
+	Ref  ValueRef
+	GoId GoroutineId // goroutine of the annotation
+}
+
+// Reply to ReqStringifyMsg.
+type StringifyMsg struct {
+	Ref ValueRef
+	Str string
+	Cut bool   // still cut by the bigger limits
+	Err string // ex: value not available
+}
+
+// Sent when a test (or subtest) starts and ends. The test runs in the goroutine that sends it.
+type TestMsg struct {
+	GoId   GoroutineId
+	Name   string // ex: "TestA/sub1"
+	Done   bool
+	Failed bool // if done
+}
 
 //----------
 
@@ -69,6 +122,7 @@ type AnnotatorFileData struct {
 type AfdFileIndex = uint16
 type AfdFileSize = uint32
 type AfdMsgIndex = uint32 // uint16 enough?
+type GoroutineId = uint64
 
 //----------
 
@@ -78,6 +132,7 @@ type OffsetMsg struct {
 	FileIndex AfdFileIndex
 	MsgIndex  AfdMsgIndex
 	Offset    AfdFileSize
+	GoId      GoroutineId // goroutine that sent the msg
 	Item      Item
 }
 
@@ -91,6 +146,11 @@ type ItemValue struct {
 	Item
 	Str string
 }
+type ItemValueRef struct { // value cut when stringified, the full value can be requested with the ref
+	Item
+	Str string
+	Ref ValueRef
+}
 type ItemList struct { // separated by ","
 	Item
 	List []Item
@@ -193,6 +253,9 @@ type ItemStep struct {
 type ItemAnon struct {
 	Item
 }
+type ItemBreak struct { // exec side is paused at the msg offset
+	Item
+}
 
 //----------
 //----------
@@ -200,7 +263,22 @@ type ItemAnon struct {
 
 // ItemValue: interface (ex: int=1, string="1")
 func IVi(v any) Item {
-	return &ItemValue{Str: stringify(v)}
+	return IVi2(v, exso.strMax, exso.strDepth)
+}
+
+// ItemValue: interface with stringify limits (ex: "//godebug:annotate:strmax=1000"); zero limits use the cmd line limits
+func IVi2(v any, max, depth int) Item {
+	if max == 0 {
+		max = exso.strMax
+	}
+	if depth == 0 {
+		depth = exso.strDepth
+	}
+	s, cut := stringifyV3b(v, max, depth)
+	if cut && exso.onExecSide {
+		return &ItemValueRef{Str: s, Ref: exs.refs.add(v)}
+	}
+	return &ItemValue{Str: s}
 }
 
 // ItemValue: string (ex: value of "?" is presented without quotes)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"runtime"
 	"strings"
 )
 
@@ -123,6 +124,25 @@ func (p *PrefixWriter) Write(data []byte) (int, error) {
 //----------
 //----------
 
+// Parses the id from the "goroutine N [...]" stack header. The runtime doesn't export the id, and this keeps the exec side without extra dependencies.
+func goroutineId() GoroutineId {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	id := GoroutineId(0)
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			break
+		}
+		id = id*10 + GoroutineId(c-'0')
+	}
+	return id
+}
+
+//----------
+//----------
+//----------
+
 const websocketEntryPath = "/editor_debug_ws"
 
 func websocketEntryPathUrl(host string) string {
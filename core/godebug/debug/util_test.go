@@ -12,3 +12,20 @@ func verboseStdout() io.Writer {
 	}
 	return nil
 }
+
+//----------
+
+func TestGoroutineId(t *testing.T) {
+	id1 := goroutineId()
+	if id1 == 0 {
+		t.Fatal("zero id")
+	}
+	if id2 := goroutineId(); id2 != id1 {
+		t.Fatalf("expecting same id: %v, %v", id1, id2)
+	}
+	ch := make(chan GoroutineId)
+	go func() { ch <- goroutineId() }()
+	if id3 := <-ch; id3 == 0 || id3 == id1 {
+		t.Fatalf("expecting other id: %v, %v", id1, id3)
+	}
+}
@@ -0,0 +1,67 @@
+package debug
+
+import (
+	"sync"
+)
+
+// Reference to a value that was cut when stringified (zero: no ref).
+type ValueRef = uint32
+
+// Limits of the stringify done when the editor requests a value that was cut.
+const (
+	valueRefStrMax   = 64 * 1024
+	valueRefStrDepth = 32
+)
+
+//----------
+
+// exec side values that were cut when stringified, kept to allow the editor to request the bigger value. Only a reference is kept (no cost at the annotation), the value is stringified when requested, by its goroutine while paused at a breakpoint (see execBreaks.runPaused), so it doesn't race with the goroutine that owns it. Only the most recent are kept.
+type valueRefs struct {
+	mu    sync.Mutex
+	max   int
+	m     map[ValueRef]any
+	queue []ValueRef // insertion order, to discard the oldest
+	last  ValueRef
+}
+
+func newValueRefs(max int) *valueRefs {
+	return &valueRefs{max: max, m: map[ValueRef]any{}}
+}
+
+func (vr *valueRefs) add(v any) ValueRef {
+	vr.mu.Lock()
+	defer vr.mu.Unlock()
+	vr.last++
+	if vr.last == 0 { // wrapped around, zero is reserved
+		vr.last++
+	}
+	ref := vr.last
+	vr.m[ref] = v
+	vr.queue = append(vr.queue, ref)
+	for len(vr.queue) > vr.max {
+		delete(vr.m, vr.queue[0])
+		vr.queue = vr.queue[1:]
+	}
+	return ref
+}
+
+func (vr *valueRefs) get(ref ValueRef) (any, bool) {
+	vr.mu.Lock()
+	defer vr.mu.Unlock()
+	v, ok := vr.m[ref]
+	return v, ok
+}
+
+//----------
+
+// Must run in the goroutine that owns the value (or while it is paused).
+func (vr *valueRefs) stringify(ref ValueRef) *StringifyMsg {
+	sm := &StringifyMsg{Ref: ref}
+	v, ok := vr.get(ref)
+	if !ok {
+		sm.Err = "value not available (discarded)"
+		return sm
+	}
+	sm.Str, sm.Cut = stringifyV3b(v, valueRefStrMax, valueRefStrDepth)
+	return sm
+}
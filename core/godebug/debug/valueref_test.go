@@ -0,0 +1,97 @@
+package debug
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValueRefs1(t *testing.T) {
+	vr := newValueRefs(2)
+	v := []int{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	r1 := vr.add(v)
+	r2 := vr.add(2)
+	r3 := vr.add(3) // discards r1
+	if r1 == 0 || r1 == r2 || r2 == r3 {
+		t.Fatal(r1, r2, r3)
+	}
+	if _, ok := vr.get(r1); ok {
+		t.Fatal("not discarded")
+	}
+	if sm := vr.stringify(r3); sm.Str != "3" {
+		t.Fatal(sm)
+	}
+
+	sm := vr.stringify(r1)
+	if sm.Err == "" {
+		t.Fatal("expecting error")
+	}
+}
+func TestValueRefs2(t *testing.T) {
+	v := []int{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	s, cut := stringifyV3b(v, 6, 0)
+	if !cut || s != "[1 2 3 ...]" {
+		t.Fatal(s, cut)
+	}
+
+	// stringified only when requested, with the bigger limits
+	vr := newValueRefs(2)
+	ref := vr.add(v)
+	v[0] = 100
+	sm := vr.stringify(ref)
+	if sm.Cut || sm.Str != "[100 2 3 4 5 6 7 8 9]" {
+		t.Fatal(sm)
+	}
+}
+func TestValueRefs3(t *testing.T) {
+	// the value is stringified by its goroutine while paused
+	tp := &testBreakProto{ch: make(chan *OffsetMsg, 10)}
+	p0 := exs.p
+	exs.p = tp
+	defer func() { exs.p = p0 }()
+
+	b := newExecBreaks()
+	b.setBreakpoints([]*Breakpoint{{FileIndex: 1, Start: 10, End: 20}})
+
+	vr := newValueRefs(4)
+	m := map[int]int{}
+	ref := ValueRef(0)
+	done := make(chan bool)
+	go func() { // program goroutine
+		defer close(done)
+		for i := 0; i < 3; i++ {
+			m[i] = i
+		}
+		ref = vr.add(m)
+		b.check(&OffsetMsg{FileIndex: 1, Offset: 12, GoId: 7})
+		m[3] = 3 // after the continue
+	}()
+
+	// not paused yet (or other goroutine)
+	if b.runPaused(8, func() {}) {
+		t.Fatal("goroutine not paused")
+	}
+	select {
+	case <-tp.ch:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for break")
+	}
+
+	res := make(chan *StringifyMsg, 1)
+	if !b.runPaused(7, func() { res <- vr.stringify(ref) }) {
+		t.Fatal("goroutine paused")
+	}
+	select {
+	case sm := <-res:
+		if sm.Str != "map[0:0 1:1 2:2]" {
+			t.Fatal(sm)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for stringify")
+	}
+
+	b.cont(&ContinueMsg{GoId: 7})
+	<-done
+	if b.runPaused(7, func() {}) {
+		t.Fatal("goroutine not paused")
+	}
+}
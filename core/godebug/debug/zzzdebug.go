@@ -36,7 +36,12 @@ var exso struct {
 	srcLines            bool                 // warning at init msg
 	syncSend            bool                 // don't send in chunks (slow)
 	stringifyBytesRunes bool                 // "abc" instead of [97 98 99]
+	strMax              int                  // stringify max length (zero: default)
+	strDepth            int                  // stringify max depth (zero: default)
+	maxMsgs             int                  // max msgs per annotation index
+	ringMsgs            bool                 // keep the last msgs (up to the max) until close
 	filesData           []*AnnotatorFileData // all debug data
+	maxHits             []*MaxHits           // "//godebug:annotate:maxhits" directives
 }
 
 //----------
@@ -50,15 +55,22 @@ type execSide struct {
 	p     Proto
 	initw *InitWait
 	logw  io.Writer
+	brk   *execBreaks
+	lim   *execLimits
+	refs  *valueRefs
 }
 
 func newExecSide() *execSide {
 	exs := &execSide{}
 	exs.initw = newInitWait()
+	exs.brk = newExecBreaks()
+	exs.lim = newExecLimits()
+	exs.refs = newValueRefs(1024)
 	return exs
 }
 func (exs *execSide) init() {
 	defer exs.initw.done()
+	exs.lim.setup(exso.filesData, exso.maxMsgs, exso.maxHits, exso.ringMsgs)
 	if !exso.noDebugMsg {
 		exs.logw = NewPrefixWriter(os.Stderr, "# godebug.exec: ")
 	}
@@ -68,6 +80,7 @@ func (exs *execSide) init() {
 		return
 	}
 	exs.initw.ok = true
+	go exs.readLoop()
 }
 func (exs *execSide) init2() error {
 	if !exso.noDebugMsg {
@@ -87,12 +100,52 @@ func (exs *execSide) init2() error {
 
 	fd := &FilesDataMsg{Data: exso.filesData}
 	pexs := &ProtoExecSide{FData: fd, NoWriteBuffering: exso.syncSend}
+	pexs.OnStart = func(rsm *ReqStartMsg) {
+		exs.brk.setBreakpoints(rsm.Breakpoints)
+	}
 	//pexs.Logger = Logger{"pexs: ", exs.logw} // DEBUG: lots of output
 
 	p, err := NewProto(ctx, exso.addr, pexs, exso.isServer, exso.continueServing, exs.logw)
 	exs.p = p
 	return err
 }
+
+// Msgs sent by the editor after the start.
+func (exs *execSide) readLoop() {
+	defer exs.brk.close() // release paused goroutines
+	for {
+		v := (any)(nil)
+		if err := exs.p.Read(&v); err != nil {
+			return
+		}
+		switch t := v.(type) {
+		case *ContinueMsg:
+			exs.brk.cont(t)
+		case *BreakpointsMsg:
+			exs.brk.setBreakpoints(t.Breakpoints)
+		case *ReqStringifyMsg:
+			exs.reqStringify(t)
+		default:
+			exs.logf("unexpected msg: %T\n", v)
+		}
+	}
+}
+
+// The value is stringified by its goroutine while paused: doesn't race with it, and has no cost at the annotation.
+func (exs *execSide) reqStringify(rsm *ReqStringifyMsg) {
+	write := func(sm *StringifyMsg) {
+		if err := exs.p.Write(sm); err != nil {
+			exs.logError(err)
+		}
+	}
+	fn := func() {
+		write(exs.refs.stringify(rsm.Ref))
+	}
+	if !exs.brk.runPaused(rsm.GoId, fn) {
+		write(&StringifyMsg{Ref: rsm.Ref, Err: "goroutine not paused at a breakpoint"})
+	}
+}
+
 func (exs *execSide) afterInitOk(fn func()) {
 	exs.initw.afterInitOk(fn)
 }
@@ -126,6 +179,13 @@ func Recover() {
 func Close() {
 	mustBeExecSide()
 	exs.afterInitOk(func() {
+		// msgs kept in ring buffers
+		for _, m := range exs.lim.takeKept() {
+			if err := exs.p.WriteMsg(m); err != nil {
+				exs.logError(err)
+				break
+			}
+		}
 		if err := exs.p.CloseOrWait(); err != nil {
 			exs.logError(err)
 		}
@@ -141,27 +201,86 @@ func Exit(code int) {
 
 // Auto-inserted at annotations. Don't use.
 // NOTE: func name is used in annotator, don't rename.
-func L(fileIndex, debugIndex, offset int, item Item) {
+// The goroutine id is obtained once at the func entry (see GoId).
+func L(fileIndex, debugIndex, offset int, item Item, goId GoroutineId) {
 	//mustBeExecSide() // commented for performance
 
+	if exs.lim.active.Load() && !exs.lim.accept(fileIndex, debugIndex) {
+		return
+	}
+
 	lmsg := &OffsetMsg{
 		FileIndex: AfdFileIndex(fileIndex),
 		MsgIndex:  AfdMsgIndex(debugIndex),
 		Offset:    AfdFileSize(offset),
+		GoId:      goId,
 		Item:      item,
 	}
+	kept := exs.lim.active.Load() && exs.lim.keep(lmsg) // sent on close
 	exs.afterInitOk(func() {
-		if err := exs.p.WriteMsg(lmsg); err != nil {
-			lineErrOnce.Do(func() {
-				exs.logError(err)
+		if !kept {
+			if err := exs.p.WriteMsg(lmsg); err != nil {
+				lineErrOnce.Do(func() {
+					exs.logError(err)
 
-				// TODO: if buffered, writemsg might not return errors, so no way to stop
-				internalExit()
-			})
+					// TODO: if buffered, writemsg might not return errors, so no way to stop
+					internalExit()
+				})
+				return
+			}
+		}
+		if exs.brk.active.Load() {
+			exs.brk.check(lmsg)
 		}
 	})
 }
 
+// Auto-inserted at the start of annotated funcs (result passed to the debug lines). Don't use.
+// Must be called in the goroutine that runs the func.
+// NOTE: func name is used in annotator, don't rename.
+func GoId() GoroutineId {
+	return goroutineId()
+}
+
+// Auto-inserted at "//godebug:break" directives. Don't use.
+// NOTE: func name is used in annotator, don't rename.
+func Break(fileIndex, offset int) {
+	lmsg := &OffsetMsg{
+		FileIndex: AfdFileIndex(fileIndex),
+		Offset:    AfdFileSize(offset),
+		GoId:      goroutineId(),
+	}
+	exs.afterInitOk(func() {
+		exs.brk.pause(lmsg)
+	})
+}
+
+// Auto-inserted at the start of funcs with a *testing.T param (tests and subtests). Don't use.
+// NOTE: func name is used in annotator, don't rename.
+func TestStart(t testingT) {
+	writeTestMsg(&TestMsg{GoId: goroutineId(), Name: t.Name()})
+}
+
+// Auto-inserted (deferred) at the start of funcs with a *testing.T param. Don't use.
+// NOTE: func name is used in annotator, don't rename.
+func TestEnd(t testingT) {
+	writeTestMsg(&TestMsg{GoId: goroutineId(), Name: t.Name(), Done: true, Failed: t.Failed()})
+}
+
+func writeTestMsg(m *TestMsg) {
+	exs.afterInitOk(func() {
+		if err := exs.p.Write(m); err != nil {
+			exs.logError(err)
+		}
+	})
+}
+
+// Implemented by *testing.T (avoids importing the testing pkg).
+type testingT interface {
+	Name() string
+	Failed() bool
+}
+
 var lineErrOnce sync.Once
 
 //----------
@@ -0,0 +1,267 @@
+package godebug
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+// Export formats of the recorded msgs (no timing data is recorded, the msgs arrival index is used as the timestamp).
+//   - json: one json object per line per msg
+//   - chrometrace: chrome/perfetto trace event file, with spans for the annotated calls (from the call enter to the call result) and instant events for the other msgs
+var ExportFormats = []string{"json", "chrometrace"}
+
+// Msg with its arrival index (as shown in the editor, can have gaps).
+type ArrivalMsg struct {
+	Arrival int
+	Msg     *debug.OffsetMsg
+}
+
+func CheckExportFormat(format string) error {
+	if !slices.Contains(ExportFormats, format) {
+		return fmt.Errorf("unknown export format: %q (expecting one of: %v)", format, strings.Join(ExportFormats, ", "))
+	}
+	return nil
+}
+
+func WriteExport(w io.Writer, format string, fdata *debug.FilesDataMsg, msgs []*ArrivalMsg) error {
+	if err := CheckExportFormat(format); err != nil {
+		return err
+	}
+	ex := newExporter(fdata)
+	if format == "chrometrace" {
+		return ex.writeChromeTrace(w, msgs)
+	}
+	return ex.writeJSONLines(w, msgs)
+}
+
+//----------
+
+type exporter struct {
+	fdata *debug.FilesDataMsg
+	lines map[debug.AfdFileIndex][]int // lines start offsets, nil if the file is not available
+}
+
+func newExporter(fdata *debug.FilesDataMsg) *exporter {
+	return &exporter{fdata: fdata, lines: map[debug.AfdFileIndex][]int{}}
+}
+
+func (ex *exporter) filename(findex debug.AfdFileIndex) string {
+	if int(findex) >= len(ex.fdata.Data) {
+		return ""
+	}
+	return ex.fdata.Data[findex].Filename
+}
+
+// Line number (1-based) of the offset; zero if the file is not available.
+func (ex *exporter) line(findex debug.AfdFileIndex, offset debug.AfdFileSize) int {
+	starts, ok := ex.lines[findex]
+	if !ok {
+		if b, err := os.ReadFile(ex.filename(findex)); err == nil {
+			starts = []int{0}
+			for i, c := range b {
+				if c == '\n' {
+					starts = append(starts, i+1)
+				}
+			}
+		}
+		ex.lines[findex] = starts
+	}
+	if starts == nil {
+		return 0
+	}
+	return sort.Search(len(starts), func(i int) bool {
+		return starts[i] > int(offset)
+	})
+}
+
+func (ex *exporter) exportMsg(arrivalIndex int, m *debug.OffsetMsg) *ExportMsg {
+	return &ExportMsg{
+		Arrival:  arrivalIndex,
+		GoId:     m.GoId,
+		Filename: ex.filename(m.FileIndex),
+		Line:     ex.line(m.FileIndex, m.Offset),
+		Offset:   m.Offset,
+		MsgIndex: m.MsgIndex,
+		Kind:     itemKind(m.Item),
+		Str:      StringifyItemFull(m.Item),
+	}
+}
+
+//----------
+
+type ExportMsg struct {
+	Arrival  int               `json:"arrival"`
+	GoId     debug.GoroutineId `json:"goid"`
+	Filename string            `json:"file"`
+	Line     int               `json:"line,omitempty"`
+	Offset   debug.AfdFileSize `json:"offset"`
+	MsgIndex debug.AfdMsgIndex `json:"msgIndex"`
+	Kind     string            `json:"kind"` // item type (ex: "ItemAssign")
+	Str      string            `json:"str"`
+}
+
+func (ex *exporter) writeJSONLines(w io.Writer, msgs []*ArrivalMsg) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw) // adds a newline after each value
+	for _, am := range msgs {
+		if err := enc.Encode(ex.exportMsg(am.Arrival, am.Msg)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+//----------
+
+// https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU
+type chromeTraceEvent struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat,omitempty"`
+	Ph    string         `json:"ph"` // B=begin, E=end, i=instant
+	Ts    int            `json:"ts"`
+	Pid   int            `json:"pid"`
+	Tid   uint64         `json:"tid"`
+	Scope string         `json:"s,omitempty"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+type chromeTraceSpan struct {
+	enter string // stringified call enter, matched with the call result
+	name  string
+}
+
+func (ex *exporter) writeChromeTrace(w io.Writer, msgs []*ArrivalMsg) error {
+	events := []*chromeTraceEvent{}
+	stacks := map[debug.GoroutineId][]*chromeTraceSpan{}
+
+	ts := 0
+	endSpans := func(goId debug.GoroutineId, n int) {
+		stk := stacks[goId]
+		for i := len(stk) - 1; i >= n; i-- {
+			e := &chromeTraceEvent{Name: stk[i].name, Cat: "call", Ph: "E", Ts: ts, Pid: 1, Tid: uint64(goId)}
+			events = append(events, e)
+		}
+		stacks[goId] = stk[:n]
+	}
+
+	for _, am := range msgs {
+		m := am.Msg
+		ts = am.Arrival
+		em := ex.exportMsg(am.Arrival, m)
+		args := map[string]any{"file": em.Filename, "offset": em.Offset}
+		if em.Line > 0 {
+			args["line"] = em.Line
+		}
+
+		// call results close the spans (inner calls first)
+		walkItem(m.Item, func(item debug.Item) {
+			c, ok := item.(*debug.ItemCall)
+			if !ok || c.Enter == nil {
+				return
+			}
+			enter := StringifyItemFull(c.Enter)
+			stk := stacks[m.GoId]
+			for k := len(stk) - 1; k >= 0; k-- {
+				if stk[k].enter == enter {
+					endSpans(m.GoId, k) // also ends unmatched inner spans (ex: panic)
+					break
+				}
+			}
+		})
+
+		if ce, ok := m.Item.(*debug.ItemCallEnter); ok {
+			name := StringifyItemFull(ce.Fun)
+			e := &chromeTraceEvent{Name: name, Cat: "call", Ph: "B", Ts: ts, Pid: 1, Tid: uint64(m.GoId), Args: args}
+			events = append(events, e)
+			span := &chromeTraceSpan{enter: StringifyItemFull(ce), name: name}
+			stacks[m.GoId] = append(stacks[m.GoId], span)
+			continue
+		}
+
+		args["str"] = em.Str
+		e := &chromeTraceEvent{Name: em.Kind, Cat: "msg", Ph: "i", Ts: ts, Pid: 1, Tid: uint64(m.GoId), Scope: "t", Args: args}
+		events = append(events, e)
+	}
+
+	// end the spans that had no result (ex: program exited)
+	ts++
+	goIds := []debug.GoroutineId{}
+	for id := range stacks {
+		goIds = append(goIds, id)
+	}
+	sort.Slice(goIds, func(a, b int) bool { return goIds[a] < goIds[b] })
+	for _, id := range goIds {
+		endSpans(id, 0)
+	}
+
+	u := struct {
+		TraceEvents []*chromeTraceEvent `json:"traceEvents"`
+	}{events}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	if err := enc.Encode(&u); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+//----------
+
+// Item type name without the package (ex: "ItemCall").
+func itemKind(item debug.Item) string {
+	if item == nil {
+		return ""
+	}
+	t := reflect.TypeOf(item)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// Visits the items in post-order (inner items first).
+func walkItem(item debug.Item, fn func(debug.Item)) {
+	if item == nil {
+		return
+	}
+	v := reflect.ValueOf(item)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Struct {
+		itemType := reflect.TypeOf((*debug.Item)(nil)).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Field(i)
+			if v.Type().Field(i).Anonymous { // embedded Item interface
+				continue
+			}
+			switch {
+			case f.Kind() == reflect.Slice:
+				for k := 0; k < f.Len(); k++ {
+					if u, ok := f.Index(k).Interface().(debug.Item); ok {
+						walkItem(u, fn)
+					}
+				}
+			case f.Type().Implements(itemType):
+				if u, ok := f.Interface().(debug.Item); ok {
+					walkItem(u, fn)
+				}
+			}
+		}
+	}
+	fn(item)
+}
@@ -0,0 +1,78 @@
+package godebug
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+func TestExport1(t *testing.T) {
+	fdata := &debug.FilesDataMsg{Data: []*debug.AnnotatorFileData{
+		{FileIndex: 0, NMsgIndexes: 3, Filename: "/not/found/main.go"},
+	}}
+	enter := func(name string) *debug.ItemCallEnter {
+		return debug.ICe(debug.IVs(name), debug.IL()).(*debug.ItemCallEnter)
+	}
+	call := func(name string, res int) debug.Item {
+		return debug.IC(enter(name), debug.IVi(res))
+	}
+	omsgs := []*debug.OffsetMsg{
+		{Item: enter("f1"), GoId: 1},
+		{Item: debug.IVi(1), GoId: 1, Offset: 10},
+		{Item: enter("f2"), GoId: 1},
+		{Item: debug.IA(debug.IL(debug.IVs("a")), 47, debug.IL(call("f2", 2))), GoId: 1},
+		{Item: call("f1", 1), GoId: 1},
+		{Item: enter("f3"), GoId: 2}, // no result
+	}
+	msgs := []*ArrivalMsg{}
+	for i, m := range omsgs {
+		msgs = append(msgs, &ArrivalMsg{Arrival: 5 + i*2, Msg: m}) // gaps (ex: dropped msgs)
+	}
+
+	// json lines
+	buf := &bytes.Buffer{}
+	if err := WriteExport(buf, "json", fdata, msgs); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(msgs) {
+		t.Fatal(len(lines))
+	}
+	em := &ExportMsg{}
+	if err := json.Unmarshal([]byte(lines[1]), em); err != nil {
+		t.Fatal(err)
+	}
+	if em.Arrival != 7 || em.Offset != 10 || em.Kind != "ItemValue" || em.Str != "1" || em.Filename != "/not/found/main.go" {
+		t.Fatalf("%+v", em)
+	}
+
+	// chrome trace
+	buf.Reset()
+	if err := WriteExport(buf, "chrometrace", fdata, msgs); err != nil {
+		t.Fatal(err)
+	}
+	u := struct{ TraceEvents []*chromeTraceEvent }{}
+	if err := json.Unmarshal(buf.Bytes(), &u); err != nil {
+		t.Fatal(err)
+	}
+	spans := []string{}
+	for _, e := range u.TraceEvents {
+		if e.Ph != "i" {
+			spans = append(spans, e.Ph+":"+e.Name)
+		}
+	}
+	s := strings.Join(spans, ",")
+	if s != "B:f1,B:f2,E:f2,E:f1,B:f3,E:f3" {
+		t.Fatal(s)
+	}
+
+	if err := CheckExportFormat("abc"); err == nil {
+		t.Fatal("expecting error")
+	}
+	if err := WriteExport(buf, "abc", fdata, msgs); err == nil {
+		t.Fatal("expecting error")
+	}
+}
@@ -26,6 +26,8 @@ type FilesToAnnotate struct {
 
 	toAnnotate   map[string]AnnotationType   // map[filename]
 	nodeAnnTypes map[ast.Node]AnnotationType // map[*ast.File and inner ast.Node's, check how a file is added for annotation]
+	breakNodes   map[ast.Node]bool           // stmts with a break directive
+	limitNodes   map[ast.Node]*AnnotationLimit
 
 	loadPkgs []*packages.Package
 }
@@ -37,6 +39,8 @@ func NewFilesToAnnotate(cmd *Cmd) *FilesToAnnotate {
 	fa.filesAsts = map[string]*ast.File{}
 	fa.toAnnotate = map[string]AnnotationType{}
 	fa.nodeAnnTypes = map[ast.Node]AnnotationType{}
+	fa.breakNodes = map[ast.Node]bool{}
+	fa.limitNodes = map[ast.Node]*AnnotationLimit{}
 	return fa
 }
 
@@ -267,6 +271,24 @@ func (fa *FilesToAnnotate) addFromSrcDirectivesFile(filename string, astFile *as
 
 	// keep node map for annotation phase
 	for _, opt := range opts {
+		if opt.Type == AnnotationTypeBreak {
+			// separate map: the same node can have other directives
+			fa.breakNodes[opt.Node] = true
+			continue
+		}
+		if opt.Type == AnnotationTypeLimit {
+			lim, err := parseAnnotationLimit(opt.Opt)
+			if err != nil {
+				return positionError(fa.cmd.fset, opt.Comment.Pos(), err)
+			}
+			// before the package clause: applies to the whole file
+			node := opt.Node
+			if opt.Comment.Pos() < astFile.Package {
+				node = astFile
+			}
+			fa.limitNodes[node] = fa.limitNodes[node].merge(lim)
+			continue
+		}
 		fa.nodeAnnTypes[opt.Node] = opt.Type
 	}
 	// add filenames to annotate from annotations
@@ -285,6 +307,10 @@ func (fa *FilesToAnnotate) addFromAnnOpt(opt *AnnotationOpt) error {
 		return nil
 	case AnnotationTypeOff:
 		return nil
+	case AnnotationTypeBreak:
+		return nil
+	case AnnotationTypeLimit:
+		return nil
 	case AnnotationTypeBlock:
 		return fa.addNodeFilename(opt.Node, opt.Type)
 	case AnnotationTypeFile:
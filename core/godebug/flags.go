@@ -27,24 +27,30 @@ type Flags struct {
 		test    bool
 		build   bool
 		connect bool
+		prepare bool
 	}
 
 	address             string // build/connect
 	continueServing     bool
 	editorIsServer      bool
 	env                 []string
+	maxMsgs             int // max msgs per annotation index
 	network             string
 	noDebugMsg          bool
 	outFilename         string   // build, ex: -o filename
 	paths               []string // dirs/files to annotate (args from cmd line)
+	ringMsgs            bool     // keep the last msgs (up to the max), sent at exit
 	srcLines            bool
 	startExec           bool
+	strDepth            int // stringify max depth of values
+	strMax              int // stringify max length of values
 	stringifyBytesRunes bool
 	syncSend            bool
 	toolExec            string // ex: "wine" will run "wine args..."
 	usePkgLinks         bool
 	verbose             bool
 	work                bool
+	workDir             string // prepare: dir for the generated files
 
 	unknownArgs []string // unknown args to pass down to tooling
 	unnamedArgs []string // args without name (ex: filenames)
@@ -76,6 +82,9 @@ func (fl *Flags) parseArgs(args []string) error {
 	case "connect":
 		fl.mode.connect = true
 		return fl.parseConnectArgs(name, args[1:])
+	case "prepare":
+		fl.mode.prepare = true
+		return fl.parsePrepareArgs(name, args[1:])
 	default:
 		return fl.usagePrintAndErr()
 	}
@@ -94,12 +103,16 @@ func (fl *Flags) parseRunArgs(name string, args []string) error {
 	fl.addAddrFlag(fs, "")
 	fl.addEditorIsServerFlag(fs)
 	fl.addEnvFlag(fs)
+	fl.addMaxMsgsFlag(fs)
 	fl.addNetworkFlag(fs)
 	fl.addNoDebugMsgFlag(fs)
 	fl.addOutFilenameFlag(fs)
 	fl.addPathsFlag(fs)
+	fl.addRingMsgsFlag(fs)
 	fl.addSrcLinesFlag(fs)
 	fl.addStartExecFlag(fs)
+	fl.addStrDepthFlag(fs)
+	fl.addStrMaxFlag(fs)
 	fl.addStringifyBytesRunesFlag(fs)
 	fl.addSyncSendFlag(fs)
 	fl.addToolExecFlag(fs)
@@ -125,11 +138,15 @@ func (fl *Flags) parseTestArgs(name string, args []string) error {
 	fl.addAddrFlag(fs, "")
 	fl.addEditorIsServerFlag(fs)
 	fl.addEnvFlag(fs)
+	fl.addMaxMsgsFlag(fs)
 	fl.addNetworkFlag(fs)
 	fl.addNoDebugMsgFlag(fs)
 	fl.addPathsFlag(fs)
+	fl.addRingMsgsFlag(fs)
 	fl.addSrcLinesFlag(fs)
 	fl.addStartExecFlag(fs)
+	fl.addStrDepthFlag(fs)
+	fl.addStrMaxFlag(fs)
 	fl.addStringifyBytesRunesFlag(fs)
 	fl.addSyncSendFlag(fs)
 	fl.addTestRunFlag(fs)
@@ -150,11 +167,15 @@ func (fl *Flags) parseBuildArgs(name string, args []string) error {
 	fl.addContinueServingFlag(fs)
 	fl.addEditorIsServerFlag(fs)
 	fl.addEnvFlag(fs)
+	fl.addMaxMsgsFlag(fs)
 	fl.addNetworkFlag(fs)
 	fl.addNoDebugMsgFlag(fs)
 	fl.addOutFilenameFlag(fs)
 	fl.addPathsFlag(fs)
+	fl.addRingMsgsFlag(fs)
 	fl.addSrcLinesFlag(fs)
+	fl.addStrDepthFlag(fs)
+	fl.addStrMaxFlag(fs)
 	fl.addStringifyBytesRunesFlag(fs)
 	fl.addSyncSendFlag(fs)
 	fl.addUsePkgLinksFlag(fs)
@@ -183,6 +204,31 @@ func (fl *Flags) parseConnectArgs(name string, args []string) error {
 	return fs.Parse(args)
 }
 
+func (fl *Flags) parsePrepareArgs(name string, args []string) error {
+	fs := fl.newFlagSet(name)
+
+	fl.addAddrFlag(fs, defaultBuildConnectAddr)
+	fl.addContinueServingFlag(fs)
+	fl.addEditorIsServerFlag(fs)
+	fl.addEnvFlag(fs)
+	fl.addMaxMsgsFlag(fs)
+	fl.addNetworkFlag(fs)
+	fl.addNoDebugMsgFlag(fs)
+	fl.addPathsFlag(fs)
+	fl.addRingMsgsFlag(fs)
+	fl.addSrcLinesFlag(fs)
+	fl.addStrDepthFlag(fs)
+	fl.addStrMaxFlag(fs)
+	fl.addStringifyBytesRunesFlag(fs)
+	fl.addSyncSendFlag(fs)
+	fl.addUsePkgLinksFlag(fs)
+	fl.addVerboseFlag(fs)
+	fl.addWorkDirFlag(fs)
+
+	m := goBuildBooleanFlags()
+	return fl.parse(name, fs, args, m)
+}
+
 //----------
 
 func (fl *Flags) addAddrFlag(fs *flag.FlagSet, def string) {
@@ -208,6 +254,10 @@ func (fl *Flags) addEnvFlag(fs *flag.FlagSet) {
 	fs.Var(ff, "env", usage)
 }
 
+func (fl *Flags) addMaxMsgsFlag(fs *flag.FlagSet) {
+	fs.IntVar(&fl.maxMsgs, "maxmsgs", 0, "max msgs sent per annotation index, the others are dropped on the exec side (0: no limit). Also see the \"//godebug:annotate:maxhits=<n>\" source code directive.")
+}
+
 func (fl *Flags) addNetworkFlag(fs *flag.FlagSet) {
 	fs.StringVar(&fl.network, "network", "", "protocol to use to transmit debug data: [tcp, ws, unix, auto]. Option 'auto' detects a tcp client request to auto upgrade to http/websocket. Ex: useful to alternate between a debug session for a server (tcp) and a brower (websocket), without restarting the godebug cmd (use with -continueserving).")
 }
@@ -228,6 +278,10 @@ func (fl *Flags) addPathsFlag(fs *flag.FlagSet) {
 	fs.Var(ff, "paths", "comma-separated `string` of dirs/files to annotate (also see the \"//godebug:annotate*\" source code directives to set files to be annotated)")
 }
 
+func (fl *Flags) addRingMsgsFlag(fs *flag.FlagSet) {
+	fs.BoolVar(&fl.ringMsgs, "ring", false, "keep the last msgs instead of the first ones for the limited annotations (-maxmsgs, maxhits directive); kept msgs are only sent when the program exits or panics")
+}
+
 func (fl *Flags) addSrcLinesFlag(fs *flag.FlagSet) {
 	fs.BoolVar(&fl.srcLines, "srclines", true, "add src reference lines to the compilation such that in case of panics, the stack refers to the original src file")
 }
@@ -240,6 +294,14 @@ func (fl *Flags) addSyncSendFlag(fs *flag.FlagSet) {
 	fs.BoolVar(&fl.syncSend, "syncsend", false, "Don't send msgs in chunks (slow). Useful to get msgs before a crash.")
 }
 
+func (fl *Flags) addStrDepthFlag(fs *flag.FlagSet) {
+	fs.IntVar(&fl.strDepth, "strdepth", 7, "max depth of nested values (structs, slices, maps) when stringifying values. Also see the \"//godebug:annotate:strdepth=<n>\" source code directive.")
+}
+
+func (fl *Flags) addStrMaxFlag(fs *flag.FlagSet) {
+	fs.IntVar(&fl.strMax, "strmax", 150, "max length of the stringified values; cut values can be clicked to request the full value while the goroutine is paused at a breakpoint. Also see the \"//godebug:annotate:strmax=<n>\" source code directive.")
+}
+
 func (fl *Flags) addStringifyBytesRunesFlag(fs *flag.FlagSet) {
 	fs.BoolVar(&fl.stringifyBytesRunes, "sbr", true, "Stringify bytes/runes as string (ex: [97 98 99] outputs as \"abc\")")
 }
@@ -260,6 +322,10 @@ func (fl *Flags) addWorkFlag(fs *flag.FlagSet) {
 	fs.BoolVar(&fl.work, "work", false, "print workdir and don't cleanup on exit")
 }
 
+func (fl *Flags) addWorkDirFlag(fs *flag.FlagSet) {
+	fs.StringVar(&fl.workDir, "workdir", "", "`dir` to write the generated files (overlay, debug pkg, go.mod), kept on exit (default: new tmp dir)")
+}
+
 //----------
 
 func (fl *Flags) addTestVFlag(fs *flag.FlagSet) {
@@ -321,6 +387,7 @@ The commands are:
 	test		test packages compiled with godebug data
 	build 	build binary with godebug data (allows remote debug)
 	connect	connect to a binary built with godebug data (allows remote debug)
+	prepare	generate the godebug files into a work dir and print the flags for an external build (ex: makefile, bazel)
 Env variables:
 	GODEBUG_BUILD_FLAGS	comma separated flags for build
 Examples:
@@ -329,6 +396,8 @@ Examples:
 	GoDebug run main.go -arg1 -arg2
 	GoDebug run -paths=dir1,file2.go,dir3 main.go -arg1 -arg2
 	GoDebug run -tags=xproto main.go
+	GoDebug run -maxmsgs=100 -ring main.go
+	GoDebug run -strmax=1000 -strdepth=10 main.go
 	GoDebug run -env=GODEBUG_BUILD_FLAGS=-cover main.go
 	GoDebug run -network=ws -startexec=false -env=GOOS=js:GOARCH=wasm -o=static/main.wasm client/main.go
 	GoDebug test
@@ -342,6 +411,8 @@ Examples:
 	GoDebug connect -addr=:8078
 	GoDebug connect -network=ws -addr=:8078
 	GoDebug connect -network=auto --continueserving
+	GoDebug prepare -help
+	GoDebug prepare -workdir=/tmp/gd -addr=:8078 ./cmd/server
 `
 }
 
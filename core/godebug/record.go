@@ -0,0 +1,122 @@
+package godebug
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+// Recorded session file format:
+//   - magic line
+//   - files data msg
+//   - offset msgs in chunks (encoded slices have a max length)
+const recordMagic = "editor godebug record v1\n"
+
+const recordChunkLen = 4 * 1024
+
+func WriteRecord(w io.Writer, fdata *debug.FilesDataMsg, msgs []*debug.OffsetMsg) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(recordMagic); err != nil {
+		return err
+	}
+	if err := debug.Encode(bw, fdata); err != nil {
+		return err
+	}
+	for len(msgs) > 0 {
+		n := min(len(msgs), recordChunkLen)
+		u := debug.OffsetMsgs(msgs[:n])
+		if err := debug.Encode(bw, &u); err != nil {
+			return err
+		}
+		msgs = msgs[n:]
+	}
+	return bw.Flush()
+}
+
+func ReadRecord(r io.Reader) (*debug.FilesDataMsg, []*debug.OffsetMsg, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(recordMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != recordMagic {
+		return nil, nil, fmt.Errorf("not a godebug record")
+	}
+
+	fdata := (*debug.FilesDataMsg)(nil)
+	if err := debug.Decode(br, &fdata); err != nil {
+		return nil, nil, fmt.Errorf("files data: %w", err)
+	}
+	if fdata == nil {
+		return nil, nil, fmt.Errorf("files data is nil")
+	}
+
+	msgs := []*debug.OffsetMsg{}
+	for {
+		u := debug.OffsetMsgs{}
+		if err := debug.Decode(br, &u); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, nil, fmt.Errorf("msgs: %w", err)
+		}
+		msgs = append(msgs, u...)
+	}
+
+	// check indexes
+	for _, m := range msgs {
+		if int(m.FileIndex) >= len(fdata.Data) {
+			return nil, nil, fmt.Errorf("bad file index: %v len=%v", m.FileIndex, len(fdata.Data))
+		}
+	}
+
+	return fdata, msgs, nil
+}
+
+//----------
+
+// Checks the recorded files against the files on disk using the recorded hash. Files not found at the recorded location (ex: recorded on another machine) are searched in dir by trying the suffixes of the recorded filename; when found, the filename is updated. Returns the filenames that don't match.
+func CheckRecordFiles(fdata *debug.FilesDataMsg, dir string) []string {
+	res := []string{}
+	for _, afd := range fdata.Data {
+		if recordFileMatches(afd, afd.Filename) {
+			continue
+		}
+		if filename, ok := findRecordFile(afd, dir); ok {
+			afd.Filename = filename
+			continue
+		}
+		res = append(res, afd.Filename)
+	}
+	return res
+}
+
+func findRecordFile(afd *debug.AnnotatorFileData, dir string) (string, bool) {
+	if dir == "" {
+		return "", false
+	}
+	u := strings.Split(filepath.ToSlash(afd.Filename), "/")
+	for i := range u {
+		suffix := filepath.FromSlash(strings.Join(u[i:], "/"))
+		if suffix == "" {
+			continue
+		}
+		filename := filepath.Join(dir, suffix)
+		if recordFileMatches(afd, filename) {
+			return filename, true
+		}
+	}
+	return "", false
+}
+
+func recordFileMatches(afd *debug.AnnotatorFileData, filename string) bool {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return false
+	}
+	return len(b) == int(afd.FileSize) && bytes.Equal(SourceHash(b), afd.FileHash)
+}
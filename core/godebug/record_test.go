@@ -0,0 +1,75 @@
+package godebug
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+func TestRecord1(t *testing.T) {
+	src := []byte("package main\n")
+	fdata := &debug.FilesDataMsg{Data: []*debug.AnnotatorFileData{
+		{FileIndex: 0, NMsgIndexes: 2, Filename: "/ci/work/proj/a/main.go", FileSize: debug.AfdFileSize(len(src)), FileHash: SourceHash(src)},
+	}}
+	// more msgs than the max encoded slice length
+	n := 70000
+	msgs := []*debug.OffsetMsg{}
+	for i := 0; i < n; i++ {
+		m := &debug.OffsetMsg{MsgIndex: debug.AfdMsgIndex(i % 2), Offset: 3, GoId: debug.GoroutineId(i % 5), Item: debug.IVi(i)}
+		msgs = append(msgs, m)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteRecord(buf, fdata, msgs); err != nil {
+		t.Fatal(err)
+	}
+	fdata2, msgs2, err := ReadRecord(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs2) != n {
+		t.Fatalf("expecting %v msgs, got %v", n, len(msgs2))
+	}
+	if m := msgs2[n-1]; m.GoId != 4 || m.MsgIndex != 1 || StringifyItem(m.Item) != "69999" {
+		t.Fatalf("bad msg: %v %v %v", m.GoId, m.MsgIndex, StringifyItem(m.Item))
+	}
+
+	// not found
+	dir := t.TempDir()
+	if u := CheckRecordFiles(fdata2, dir); len(u) != 1 {
+		t.Fatal(u)
+	}
+
+	// found in dir by suffix
+	filename := filepath.Join(dir, "a/main.go")
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if u := CheckRecordFiles(fdata2, dir); len(u) != 0 {
+		t.Fatal(u)
+	}
+	if fdata2.Data[0].Filename != filename {
+		t.Fatal(fdata2.Data[0].Filename)
+	}
+
+	// hash mismatch
+	if err := os.WriteFile(filename, []byte("package other\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if u := CheckRecordFiles(fdata2, dir); len(u) != 1 {
+		t.Fatal(u)
+	}
+}
+
+func TestRecord2(t *testing.T) {
+	_, _, err := ReadRecord(bytes.NewBufferString("not a record"))
+	if err == nil {
+		t.Fatal("expecting error")
+	}
+}
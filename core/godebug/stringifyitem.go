@@ -24,11 +24,20 @@ func StringifyItemFull(item debug.Item) string {
 	return is.b.String()
 }
 
+// Also returns the refs of the values that were cut on the exec side (full value can be requested).
+func StringifyItemFullRefs(item debug.Item) (string, []debug.ValueRef) {
+	is := NewItemStringifier()
+	is.valueStrLen = -1 // full str
+	is.stringify(item)
+	return is.b.String(), is.refs
+}
+
 //----------
 
 type ItemStringifier struct {
 	b           *strings.Builder
 	valueStrLen int // <0 = full str
+	refs        []debug.ValueRef
 }
 
 func NewItemStringifier() *ItemStringifier {
@@ -55,6 +64,14 @@ func (is *ItemStringifier) stringify(item debug.Item) {
 			is.p(debug.SprintCutCheckQuote(is.valueStrLen, t.Str))
 		}
 
+	case *debug.ItemValueRef: // value cut on the exec side
+		is.refs = append(is.refs, t.Ref)
+		if is.valueStrLen < 0 {
+			is.p(t.Str)
+		} else {
+			is.p(debug.SprintCutCheckQuote(is.valueStrLen, t.Str))
+		}
+
 	case *debug.ItemList: // ex: func args list
 		if t == nil {
 			break
@@ -219,6 +236,8 @@ func (is *ItemStringifier) stringify(item debug.Item) {
 		is.p("#")
 	case *debug.ItemStep:
 		is.p("#")
+	case *debug.ItemBreak:
+		is.p("=> break")
 	case *debug.ItemLabel:
 		is.p("#")
 		if t.Reason != "" { // TODO: remove
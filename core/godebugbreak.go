@@ -0,0 +1,258 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jmigpin/editor/core/godebug"
+	"github.com/jmigpin/editor/core/godebug/debug"
+	"github.com/jmigpin/editor/util/iout/iorw"
+	"github.com/jmigpin/editor/util/parseutil"
+)
+
+// Line breakpoint: the annotated program pauses at the msgs with an offset in [start,end).
+type GDBreakpoint struct {
+	line       int
+	start, end int
+}
+
+//----------
+
+// Toggles a breakpoint at the cursor line. Kept across sessions; sent to the running program if any.
+func (gdm *GoDebugManager) ToggleBreakpoint(erow *ERow) error {
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+	ta := erow.Row.TextArea
+	rd := ta.RW()
+	ci := ta.CursorIndex()
+	start, end, _, err := iorw.LinesIndexes(rd, ci, ci)
+	if err != nil {
+		return err
+	}
+	line, _, err := parseutil.IndexLineColumn(rd, ci)
+	if err != nil {
+		return err
+	}
+
+	filename := erow.Info.Name()
+	on := gdm.toggleBreakpoint(filename, &GDBreakpoint{line: line, start: start, end: end})
+	s := "off"
+	if on {
+		s = "on"
+	}
+	gdm.Printf("breakpoint %v: %v:%v", s, filename, line)
+
+	gdm.gdi.Lock()
+	gdi := gdm.gdi.gdi
+	gdm.gdi.Unlock()
+	if gdi != nil {
+		// writing to the program can block, don't hold the UI goroutine
+		go func() {
+			if err := gdi.sendBreakpoints(); err != nil {
+				gdm.printError(err)
+			}
+		}()
+	}
+	return nil
+}
+
+func (gdm *GoDebugManager) toggleBreakpoint(filename string, bp *GDBreakpoint) bool {
+	gdm.breakpoints.Lock()
+	defer gdm.breakpoints.Unlock()
+	key := gdm.filenameKey(filename)
+	bps := gdm.breakpoints.m[key]
+	for i, bp2 := range bps {
+		if bp2.start == bp.start {
+			bps = append(bps[:i], bps[i+1:]...)
+			if len(bps) == 0 {
+				delete(gdm.breakpoints.m, key)
+			} else {
+				gdm.breakpoints.m[key] = bps
+			}
+			return false
+		}
+	}
+	bps = append(bps, bp)
+	sort.Slice(bps, func(a, b int) bool {
+		return bps[a].start < bps[b].start
+	})
+	gdm.breakpoints.m[key] = bps
+	return true
+}
+
+// Breakpoints of the annotated files.
+func (gdm *GoDebugManager) protoBreakpoints(afds []*debug.AnnotatorFileData) []*debug.Breakpoint {
+	gdm.breakpoints.Lock()
+	defer gdm.breakpoints.Unlock()
+	res := []*debug.Breakpoint{}
+	for _, afd := range afds {
+		for _, bp := range gdm.breakpoints.m[gdm.filenameKey(afd.Filename)] {
+			u := &debug.Breakpoint{
+				FileIndex: afd.FileIndex,
+				Start:     debug.AfdFileSize(bp.start),
+				End:       debug.AfdFileSize(bp.end),
+			}
+			res = append(res, u)
+		}
+	}
+	return res
+}
+
+func (gdm *GoDebugManager) filenameKey(name string) string {
+	if gdm.ed.FsCaseInsensitive {
+		name = strings.ToLower(name)
+	}
+	return name
+}
+
+//----------
+
+// Resumes a paused goroutine: the given id, or the goroutine of the selected annotation if paused, or the most recently paused. With step, the goroutine pauses again at its next msg.
+func (gdm *GoDebugManager) Continue(step bool, id debug.GoroutineId) error {
+	gdm.gdi.Lock()
+	defer gdm.gdi.Unlock()
+	if gdm.gdi.gdi == nil {
+		return fmt.Errorf("missing godebug instance")
+	}
+	return gdm.gdi.gdi.continue2(step, id)
+}
+
+//----------
+//----------
+//----------
+
+func (gdi *GoDebugInstance) setCmd(cmd *godebug.Cmd) {
+	gdi.cmd.Lock()
+	defer gdi.cmd.Unlock()
+	gdi.cmd.cmd = cmd
+}
+func (gdi *GoDebugInstance) protoWrite(v any) error {
+	gdi.cmd.Lock()
+	defer gdi.cmd.Unlock()
+	if gdi.cmd.cmd == nil {
+		return fmt.Errorf("program not running")
+	}
+	return gdi.cmd.cmd.ProtoWrite(v)
+}
+
+//----------
+
+func (gdi *GoDebugInstance) sendBreakpoints() error {
+	// keep the order of the sends (each sends a snapshot of all the breakpoints)
+	gdi.bpsSend.Lock()
+	defer gdi.bpsSend.Unlock()
+
+	afds, ok := gdi.di.filesData()
+	if !ok {
+		return nil // will be sent at start
+	}
+	bps := gdi.gdm.protoBreakpoints(afds)
+	return gdi.protoWrite(&debug.BreakpointsMsg{Breakpoints: bps})
+}
+
+func (gdi *GoDebugInstance) continue2(step bool, id debug.GoroutineId) error {
+	m, err := gdi.di.takePaused(id)
+	if err != nil {
+		return err
+	}
+	cm := &debug.ContinueMsg{Step: step, GoId: m.GoId}
+	return gdi.protoWrite(cm)
+}
+
+func (gdi *GoDebugInstance) onPause(m *debug.OffsetMsg) {
+	filename, ok := gdi.di.filename(int(m.FileIndex))
+	if !ok {
+		return
+	}
+	gdi.gdm.Printf("paused: %v:o=%d (goroutine %d): use GoDebugContinue or GoDebugStep", filename, m.Offset, m.GoId)
+
+	gdi.gdm.ed.UI.RunOnUIGoRoutine(func() {
+		gdi.updateAnnotations2()
+		conf := &OpenFileERowConfig{
+			FilePos:             &parseutil.FilePos{Filename: filename, Offset: int(m.Offset)},
+			RowPos:              gdi.gdm.ed.GoodRowPos(),
+			FlashVisibleOffsets: true,
+			NewIfNotExistent:    true,
+		}
+		OpenFileERow(gdi.gdm.ed, conf)
+	})
+}
+
+//----------
+//----------
+//----------
+
+func (di *GDDataIndex) filesData() ([]*debug.AnnotatorFileData, bool) {
+	di.RLock()
+	defer di.RUnlock()
+	return di.afds, di.afds != nil
+}
+
+func (di *GDDataIndex) filename(findex int) (string, bool) {
+	di.RLock()
+	defer di.RUnlock()
+	if findex < 0 || findex >= len(di.afds) {
+		return "", false
+	}
+	return di.afds[findex].Filename, true
+}
+
+func (di *GDDataIndex) resetPaused_noLock() {
+	di.paused.m = map[debug.GoroutineId]*debug.OffsetMsg{}
+	di.paused.order = nil
+	di.paused.notify = nil
+}
+
+func (di *GDDataIndex) addPaused_noLock(m *debug.OffsetMsg) {
+	if di.paused.m == nil {
+		di.resetPaused_noLock()
+	}
+	di.removePaused_noLock(m.GoId)
+	di.paused.m[m.GoId] = m
+	di.paused.order = append(di.paused.order, m.GoId)
+	di.paused.notify = append(di.paused.notify, m)
+}
+
+func (di *GDDataIndex) removePaused_noLock(id debug.GoroutineId) {
+	delete(di.paused.m, id)
+	for i, id2 := range di.paused.order {
+		if id2 == id {
+			di.paused.order = append(di.paused.order[:i], di.paused.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Returns the break msgs once.
+func (di *GDDataIndex) newPauses() []*debug.OffsetMsg {
+	di.Lock()
+	defer di.Unlock()
+	u := di.paused.notify
+	di.paused.notify = nil
+	return u
+}
+
+// Zero id: the goroutine of the selected annotation if paused, or the most recently paused.
+func (di *GDDataIndex) takePaused(id debug.GoroutineId) (*debug.OffsetMsg, error) {
+	di.Lock()
+	defer di.Unlock()
+	if len(di.paused.order) == 0 {
+		return nil, fmt.Errorf("not paused")
+	}
+	if id == 0 {
+		id = di.paused.order[len(di.paused.order)-1]
+		if msg, err := di.selectedMsg_noLock(); err == nil {
+			if _, ok := di.paused.m[msg.offsetMsg.GoId]; ok {
+				id = msg.offsetMsg.GoId
+			}
+		}
+	}
+	m, ok := di.paused.m[id]
+	if !ok {
+		return nil, fmt.Errorf("goroutine not paused: %v", id)
+	}
+	di.removePaused_noLock(id)
+	return m, nil
+}
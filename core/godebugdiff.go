@@ -0,0 +1,323 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/jmigpin/editor/core/godebug"
+	"github.com/jmigpin/editor/util/drawutil"
+	"github.com/jmigpin/editor/util/imageutil"
+)
+
+// Diff mode: compares the annotation indexes values with the previous run. Divergent lines are highlighted, and stepping only goes through the first divergent msg of each annotation index.
+func (gdm *GoDebugManager) ToggleDiff() error {
+	gdm.gdi.Lock()
+	defer gdm.gdi.Unlock()
+	if gdm.gdi.gdi == nil {
+		return fmt.Errorf("missing godebug instance")
+	}
+	gdi := gdm.gdi.gdi
+	if gdi.di.diffOff() {
+		gdm.Printf("diff: off")
+		gdi.updateAnnotations()
+		return nil
+	}
+	if gdm.gdi.prev == nil {
+		return fmt.Errorf("diff: missing previous run")
+	}
+
+	d := newGDDiff(gdm.gdi.prev, gdi.di)
+	gdi.di.setDiff(d)
+	gdm.Printf("diff: on: %v", d.summary())
+	gdi.updateAnnotationsAndShowLine(nil, gdm.ed.GoodRowPos())
+	return nil
+}
+
+// Keeps a summary of the data of a run that is being replaced, to be compared by the diff. Built in another goroutine since it stringifies all msgs.
+func (gdm *GoDebugManager) keepPrev_noLock(di *GDDataIndex) {
+	gdm.gdi.prevGen++
+	gen := gdm.gdi.prevGen
+	go func() {
+		s := newGDRunSummary(di)
+		gdm.gdi.Lock()
+		defer gdm.gdi.Unlock()
+		if gen == gdm.gdi.prevGen { // not replaced or cleared meanwhile
+			gdm.gdi.prev = s
+		}
+	}()
+}
+func (gdm *GoDebugManager) clearPrev_noLock() {
+	gdm.gdi.prevGen++
+	gdm.gdi.prev = nil
+}
+
+//----------
+
+func (gdi *GoDebugInstance) setDiffColorOps(erow *ERow, hits []*GDHitCount) {
+	ta := erow.Row.TextArea
+	lines := heatmapLines(ta.RW(), hits)
+	bg := ta.TreeThemePaletteColor("text_bg")
+	ops := []*drawutil.ColorizeOp{}
+	for _, l := range lines {
+		ops = append(ops,
+			&drawutil.ColorizeOp{Offset: l.start, Bg: imageutil.TintOrShade(bg, 0.3), Line: true},
+			&drawutil.ColorizeOp{Offset: l.end},
+		)
+	}
+	ta.SetAnnotationsColorOps(ops)
+}
+
+//----------
+//----------
+//----------
+
+// Values of a run needed by the diff (instead of keeping its whole data index).
+type GDRunSummary struct {
+	files map[string]*gdRunFile // [filename key]
+}
+
+type gdRunFile struct {
+	hash []byte
+	msgs [][]gdRunValue // [msgIndex] values in arrival order
+}
+
+type gdRunValue struct {
+	str    string
+	offset int
+}
+
+// Must not have the data index lock.
+func newGDRunSummary(di *GDDataIndex) *GDRunSummary {
+	di.RLock()
+	defer di.RUnlock()
+	s := &GDRunSummary{files: map[string]*gdRunFile{}}
+	for findex, afd := range di.afds {
+		file := di.files[findex]
+		rf := &gdRunFile{hash: afd.FileHash, msgs: make([][]gdRunValue, len(file.msgs))}
+		for h, m := range file.msgs {
+			for _, a := range m.arrivals {
+				v := gdRunValue{str: godebug.StringifyItemFull(a.offsetMsg.Item), offset: int(a.offsetMsg.Offset)}
+				rf.msgs[h] = append(rf.msgs[h], v)
+			}
+		}
+		s.files[di.FilesIndexKey(afd.Filename)] = rf
+	}
+	return s
+}
+
+//----------
+
+// Annotation indexes with different values between two runs. Updated as msgs arrive (see update_noLock).
+type GDDiff struct {
+	prev       *GDRunSummary
+	resetIndex int                    // current run reset count when the files were setup
+	files      map[int]*gdDiffFile    // [fileIndex] (current run) files being compared
+	entries    map[int][]*GDDiffEntry // [fileIndex] (current run)
+	arrivals   []int                  // first divergent msgs (current run), sorted
+	skipped    []string               // files not in both runs or with a different hash (indexes don't match)
+}
+
+type GDDiffEntry struct {
+	msgIndex     int
+	offset       int
+	arrivalIndex int    // first divergent msg in the current run, -1 if it has less msgs than the previous run
+	prev, cur    string // values at the divergence ("" if missing)
+}
+
+type gdDiffFile struct {
+	prev *gdRunFile
+	msgs []gdDiffMsg // [msgIndex]
+}
+
+type gdDiffMsg struct {
+	n     int          // current run msgs already compared (equal to the previous run)
+	entry *GDDiffEntry // divergent msg, nil if none yet
+}
+
+// Must not have the data index locks.
+func newGDDiff(prev *GDRunSummary, cur *GDDataIndex) *GDDiff {
+	cur.RLock()
+	defer cur.RUnlock()
+	d := &GDDiff{prev: prev}
+	d.update_noLock(cur)
+	return d
+}
+
+// Compares only the msgs that arrived since the last update.
+func (d *GDDiff) update_noLock(cur *GDDataIndex) {
+	if d.files == nil || d.resetIndex != cur.resetCount { // start over (ex: msgs were reset)
+		d.resetIndex = cur.resetCount
+		d.files = map[int]*gdDiffFile{}
+		d.skipped = nil
+		for findex, afd := range cur.afds {
+			pf, ok := d.prev.files[cur.FilesIndexKey(afd.Filename)]
+			if !ok || !bytes.Equal(pf.hash, afd.FileHash) || len(pf.msgs) != len(cur.files[findex].msgs) {
+				d.skipped = append(d.skipped, afd.Filename)
+				continue
+			}
+			d.files[findex] = &gdDiffFile{prev: pf, msgs: make([]gdDiffMsg, len(pf.msgs))}
+		}
+	}
+
+	d.entries = map[int][]*GDDiffEntry{}
+	d.arrivals = nil
+	for findex, df := range d.files {
+		for h := range df.msgs {
+			e, ok := df.msgs[h].update(df.prev.msgs[h], cur.files[findex].msgs[h].arrivals)
+			if !ok {
+				continue
+			}
+			e.msgIndex = h
+			d.entries[findex] = append(d.entries[findex], e)
+			if e.arrivalIndex >= 0 {
+				d.arrivals = append(d.arrivals, e.arrivalIndex)
+			}
+		}
+	}
+	sort.Ints(d.arrivals)
+}
+
+func (dm *gdDiffMsg) update(prev []gdRunValue, cur []*GDOffsetMsg) (*GDDiffEntry, bool) {
+	if dm.entry != nil {
+		return dm.entry, true
+	}
+	for ; dm.n < len(cur); dm.n++ {
+		m := cur[dm.n]
+		s := godebug.StringifyItemFull(m.offsetMsg.Item)
+		if dm.n >= len(prev) || s != prev[dm.n].str {
+			dm.entry = &GDDiffEntry{arrivalIndex: m.arrivalIndex, cur: s, offset: int(m.offsetMsg.Offset)}
+			if dm.n < len(prev) {
+				dm.entry.prev = prev[dm.n].str
+			}
+			return dm.entry, true
+		}
+	}
+	if dm.n < len(prev) { // less msgs than the previous run (so far)
+		return &GDDiffEntry{arrivalIndex: -1, prev: prev[dm.n].str, offset: prev[dm.n].offset}, true
+	}
+	return nil, false
+}
+
+func (d *GDDiff) summary() string {
+	n := 0
+	for _, w := range d.entries {
+		n += len(w)
+	}
+	s := fmt.Sprintf("%d annotation indexes differ from the previous run", n)
+	if len(d.skipped) > 0 {
+		s += fmt.Sprintf(" (%d files not compared: not in both runs or changed)", len(d.skipped))
+	}
+	if e, ok := d.first(); ok {
+		s += fmt.Sprintf("\n\tfirst at #%d\n\tprevious: %v\n\tcurrent: %v", e.arrivalIndex, e.prev, e.cur)
+	}
+	return s
+}
+
+func (d *GDDiff) first() (*GDDiffEntry, bool) {
+	if len(d.arrivals) == 0 {
+		return nil, false
+	}
+	for _, w := range d.entries {
+		for _, e := range w {
+			if e.arrivalIndex == d.arrivals[0] {
+				return e, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Index of the first arrival >= arrivalIndex.
+func (d *GDDiff) search(arrivalIndex int) int {
+	return sort.SearchInts(d.arrivals, arrivalIndex)
+}
+
+//----------
+//----------
+//----------
+
+func (di *GDDataIndex) setDiff(d *GDDiff) {
+	di.Lock()
+	defer di.Unlock()
+	d.update_noLock(di) // msgs that arrived meanwhile
+	di.diff = d
+	if len(d.arrivals) > 0 {
+		di.selected.arrivalIndex = d.arrivals[0] // where the runs split
+	}
+}
+
+// Compares the msgs that arrived since the last update, if in diff mode.
+func (di *GDDataIndex) updateDiff() {
+	di.Lock()
+	defer di.Unlock()
+	if di.diff != nil {
+		di.diff.update_noLock(di)
+	}
+}
+
+// Returns true if it was on.
+func (di *GDDataIndex) diffOff() bool {
+	di.Lock()
+	defer di.Unlock()
+	on := di.diff != nil
+	di.diff = nil
+	return on
+}
+
+// Divergent annotations of the file, to be highlighted; false if not in diff mode.
+func (di *GDDataIndex) diffHits(filename string) ([]*GDHitCount, bool) {
+	di.RLock()
+	defer di.RUnlock()
+	if di.diff == nil {
+		return nil, false
+	}
+	findex, ok := di.FilesIndex(filename)
+	if !ok {
+		return nil, false
+	}
+	res := []*GDHitCount{}
+	for _, e := range di.diff.entries[findex] {
+		res = append(res, &GDHitCount{msgIndex: e.msgIndex, offset: e.offset, count: 1})
+	}
+	return res, true
+}
+
+func (di *GDDataIndex) selectDiffStep_noLock(step gdStep) error {
+	w := di.diff.arrivals
+	if len(w) == 0 {
+		return fmt.Errorf("diff: no divergent msgs in the current run")
+	}
+	sel := di.selected.arrivalIndex
+	k := 0
+	switch step {
+	case gdStepFirst:
+		k = 0
+	case gdStepLast:
+		k = len(w) - 1
+	case gdStepPrev:
+		k = di.diff.search(sel) - 1 // last below selected
+		if sel < 0 {
+			k = len(w) - 1
+		}
+		if k < 0 {
+			return fmt.Errorf("diff: already at first divergent msg")
+		}
+	case gdStepNext:
+		k = di.diff.search(sel + 1) // first above selected
+		if k >= len(w) {
+			return fmt.Errorf("diff: already at last divergent msg")
+		}
+	}
+	if w[k] == sel {
+		return fmt.Errorf("diff: already at index %v", sel)
+	}
+	di.selected.arrivalIndex = w[k]
+	return nil
+}
+
+func (di *GDDataIndex) hasFilesData() bool {
+	di.RLock()
+	defer di.RUnlock()
+	return di.afds != nil
+}
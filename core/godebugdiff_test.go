@@ -0,0 +1,94 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+func TestGDDiff1(t *testing.T) {
+	gdm := &GoDebugManager{ed: &Editor{}}
+	newDi := func(values ...int) *GDDataIndex {
+		di := NewGDDataIndex(&GoDebugInstance{gdm: gdm})
+		fdm := &debug.FilesDataMsg{Data: []*debug.AnnotatorFileData{
+			{FileIndex: 0, NMsgIndexes: 2, Filename: "/a/main.go", FileHash: []byte{1}},
+		}}
+		if err := di.handleFilesDataMsg(fdm); err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range values {
+			m := &debug.OffsetMsg{MsgIndex: debug.AfdMsgIndex(i % 2), Offset: debug.AfdFileSize(i % 2), Item: debug.IVi(v)}
+			if err := di.handleOffsetMsgs(m); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return di
+	}
+	prev := newGDRunSummary(newDi(1, 2, 1, 3, 1, 4))
+	cur := newDi(1, 2, 1, 5, 7, 6, 1) // index 1 diverges at #3, index 0 at #4
+
+	d := newGDDiff(prev, cur)
+	if len(d.entries[0]) != 2 {
+		t.Fatal(len(d.entries[0]))
+	}
+	if len(d.arrivals) != 2 || d.arrivals[0] != 3 || d.arrivals[1] != 4 {
+		t.Fatal(d.arrivals)
+	}
+	if e, ok := d.first(); !ok || e.prev != "3" || e.cur != "5" {
+		t.Fatal(e)
+	}
+
+	// stepping only through the divergent msgs
+	cur.setDiff(d)
+	if cur.selected.arrivalIndex != 3 {
+		t.Fatal(cur.selected.arrivalIndex)
+	}
+	if err := cur.selectNext(); err != nil {
+		t.Fatal(err)
+	}
+	if cur.selected.arrivalIndex != 4 {
+		t.Fatal(cur.selected.arrivalIndex)
+	}
+	if err := cur.selectNext(); err == nil {
+		t.Fatal("expecting error")
+	}
+	if err := cur.selectFirst(); err != nil || cur.selected.arrivalIndex != 3 {
+		t.Fatal(err, cur.selected.arrivalIndex)
+	}
+
+	// msgs arriving after the diff is on are compared
+	cur2 := newDi(1, 2, 1, 3) // both indexes have less msgs so far
+	cur2.setDiff(newGDDiff(prev, cur2))
+	if len(cur2.diff.entries[0]) != 2 || len(cur2.diff.arrivals) != 0 {
+		t.Fatal(cur2.diff.entries, cur2.diff.arrivals)
+	}
+	for i, v := range []int{1, 5} {
+		m := &debug.OffsetMsg{MsgIndex: debug.AfdMsgIndex(i % 2), Offset: debug.AfdFileSize(i % 2), Item: debug.IVi(v)}
+		if err := cur2.handleOffsetMsgs(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cur2.updateDiff()
+	if len(cur2.diff.entries[0]) != 1 || len(cur2.diff.arrivals) != 1 || cur2.diff.arrivals[0] != 5 {
+		t.Fatal(cur2.diff.entries, cur2.diff.arrivals)
+	}
+	if e, ok := cur2.diff.first(); !ok || e.prev != "4" || e.cur != "5" {
+		t.Fatal(e)
+	}
+
+	// different hash: not compared
+	cur.afds[0].FileHash = []byte{2}
+	d2 := newGDDiff(prev, cur)
+	if len(d2.skipped) != 1 || len(d2.arrivals) != 0 {
+		t.Fatal(d2.skipped, d2.arrivals)
+	}
+
+	// new files data: diff and heatmap are reset
+	cur.toggleHeatmap()
+	if err := cur.handleFilesDataMsg(&debug.FilesDataMsg{}); err != nil {
+		t.Fatal(err)
+	}
+	if cur.diff != nil || cur.heatmap {
+		t.Fatal("diff/heatmap not reset")
+	}
+}
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jmigpin/editor/util/drawutil"
+	"github.com/jmigpin/editor/util/imageutil"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// Heatmap view mode: annotated lines show how many times they executed instead of the values.
+func (gdm *GoDebugManager) ToggleHeatmap() error {
+	gdm.gdi.Lock()
+	defer gdm.gdi.Unlock()
+	if gdm.gdi.gdi == nil {
+		return fmt.Errorf("missing godebug instance")
+	}
+	gdi := gdm.gdi.gdi
+	on := gdi.di.toggleHeatmap()
+	s := "off"
+	if on {
+		s = "on"
+	}
+	gdm.Printf("heatmap: %v", s)
+	gdi.updateAnnotations()
+	return nil
+}
+
+//----------
+
+// Entries are indexed by msg index (as the values annotations) to keep clicks working. Must run in UI goroutine.
+func (gdi *GoDebugInstance) setHeatmap(erow *ERow, hits []*GDHitCount, nMsgs int) {
+	ta := erow.Row.TextArea
+	lines := heatmapLines(ta.RW(), hits)
+
+	max := 0
+	for _, l := range lines {
+		if l.count > max {
+			max = l.count
+		}
+	}
+
+	bg := ta.TreeThemePaletteColor("text_bg")
+	entries := &drawutil.AnnotationGroup{Anns: make([]*drawutil.Annotation, nMsgs)}
+	ops := []*drawutil.ColorizeOp{}
+	for _, l := range lines {
+		ann := &drawutil.Annotation{
+			Offset:     l.offset,
+			Bytes:      []byte(fmt.Sprintf("%d", l.count)),
+			NotesBytes: []byte("hits"),
+		}
+		entries.Anns[l.msgIndex] = ann
+
+		v := 0.05 + 0.35*float64(l.count)/float64(max)
+		ops = append(ops,
+			&drawutil.ColorizeOp{Offset: l.start, Bg: imageutil.TintOrShade(bg, v), Line: true},
+			&drawutil.ColorizeOp{Offset: l.end},
+		)
+	}
+	gdi.setAnnotations(erow, -1, entries)
+	ta.SetAnnotationsColorOps(ops)
+}
+
+//----------
+
+type GDHitCount struct {
+	msgIndex int
+	offset   int
+	count    int // number of arrivals
+}
+
+type gdHeatmapLine struct {
+	start, end int // end excludes the newline
+	msgIndex   int // annotation with the most hits
+	offset     int
+	count      int // max hits of the annotations in the line
+}
+
+// Hits grouped by line, ordered by offset. A line executed count is the max count of its annotations.
+func heatmapLines(rd iorw.ReaderAt, hits []*GDHitCount) []*gdHeatmapLine {
+	m := map[int]*gdHeatmapLine{} // [lineStart]
+	for _, h := range hits {
+		s, err := iorw.LineStartIndex(rd, h.offset)
+		if err != nil {
+			continue
+		}
+		l, ok := m[s]
+		if !ok {
+			e, newline, err := iorw.LineEndIndex(rd, h.offset)
+			if err != nil {
+				continue
+			}
+			if newline {
+				e--
+			}
+			l = &gdHeatmapLine{start: s, end: e}
+			m[s] = l
+		}
+		if h.count > l.count {
+			l.count = h.count
+			l.msgIndex = h.msgIndex
+			l.offset = h.offset
+		}
+	}
+	w := []*gdHeatmapLine{}
+	for _, l := range m {
+		w = append(w, l)
+	}
+	sort.Slice(w, func(a, b int) bool {
+		return w[a].start < w[b].start
+	})
+	return w
+}
+
+//----------
+//----------
+//----------
+
+func (di *GDDataIndex) toggleHeatmap() bool {
+	di.Lock()
+	defer di.Unlock()
+	di.heatmap = !di.heatmap
+	return di.heatmap
+}
+
+// Hit counts of the file annotations, and the number of msg indexes; false if not in heatmap mode.
+func (di *GDDataIndex) heatmapHits(filename string) ([]*GDHitCount, int, bool) {
+	di.RLock()
+	defer di.RUnlock()
+	if !di.heatmap {
+		return nil, 0, false
+	}
+	findex, ok := di.FilesIndex(filename)
+	if !ok {
+		return nil, 0, false
+	}
+	file := di.files[findex]
+	res := []*GDHitCount{}
+	for h, m := range file.msgs {
+		if len(m.arrivals) == 0 {
+			continue
+		}
+		u := &GDHitCount{
+			msgIndex: h,
+			offset:   int(m.arrivals[0].offsetMsg.Offset),
+			count:    len(m.arrivals),
+		}
+		res = append(res, u)
+	}
+	return res, len(file.msgs), true
+}
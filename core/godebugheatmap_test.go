@@ -0,0 +1,33 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func TestHeatmapLines(t *testing.T) {
+	src := "a:=0\nfor i:=0;i<9;i++{\n\ta+=i\n}"
+	rd := iorw.NewStringReaderAt(src)
+	hits := []*GDHitCount{
+		{msgIndex: 0, offset: 0, count: 1},
+		{msgIndex: 1, offset: 9, count: 10}, // cond
+		{msgIndex: 2, offset: 14, count: 9}, // post
+		{msgIndex: 3, offset: 24, count: 9}, // body
+		{msgIndex: 4, offset: 29, count: 1}, // last line without newline
+	}
+	lines := heatmapLines(rd, hits)
+	if len(lines) != 4 {
+		t.Fatalf("expecting 4 lines, got %v", len(lines))
+	}
+	l := lines[1]
+	if l.start != 5 || l.end != 22 || l.count != 10 || l.msgIndex != 1 {
+		t.Fatalf("bad line: %+v", *l)
+	}
+	if l := lines[2]; l.start != 23 || l.end != 28 || l.count != 9 {
+		t.Fatalf("bad line: %+v", *l)
+	}
+	if l := lines[3]; l.start != 29 || l.end != 30 {
+		t.Fatalf("bad line: %+v", *l)
+	}
+}
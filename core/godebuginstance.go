@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -35,14 +36,21 @@ type GoDebugManager struct {
 	ed  *Editor
 	gdi struct {
 		sync.Mutex
-		gdi    *GoDebugInstance
-		cancel context.CancelFunc
+		gdi     *GoDebugInstance
+		cancel  context.CancelFunc
+		prev    *GDRunSummary // previous run data, used by the diff mode (kept only when replaced by a new run)
+		prevGen int           // discards summaries being built for replaced or cleared runs
+	}
+	breakpoints struct {
+		sync.Mutex
+		m map[string][]*GDBreakpoint // [filename key]
 	}
 }
 
 func NewGoDebugManager(ed *Editor) *GoDebugManager {
 	gdm := &GoDebugManager{ed: ed}
 	gdm.gdi.cancel = func() {}
+	gdm.breakpoints.m = map[string][]*GDBreakpoint{}
 	return gdm
 }
 
@@ -68,17 +76,75 @@ func (gdm *GoDebugManager) RunAsync(startCtx context.Context, erow *ERow, args [
 	return nil
 }
 
+// Loads a recorded session (see Save) into a new instance without running a cmd. Relative filenames are relative to dir. Slow (file io, decoding), should not run in the UI goroutine; the lock is only held to replace the current instance.
+func (gdm *GoDebugManager) Load(ctx context.Context, dir, filename string, w io.Writer) error {
+	ctx2, cancel := context.WithCancel(context.Background())
+	gdi := &GoDebugInstance{gdm: gdm, ctx: ctx2, cancel: cancel}
+	gdi.di = NewGDDataIndex(gdi)
+	if err := gdi.load(dir, filename, w); err != nil {
+		cancel()
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		cancel()
+		return err
+	}
+
+	gdm.gdi.Lock()
+	defer gdm.gdi.Unlock()
+	gdm.cancelAndWaitAndClear2() // previous instance
+	gdm.gdi.cancel = cancel
+	gdm.gdi.gdi = gdi
+	gdi.updateAnnotations()
+	return nil
+}
+
+// Saves the files data and the received msgs of the current instance. Relative filenames are relative to dir. Slow (encoding, file io), should not run in the UI goroutine.
+func (gdm *GoDebugManager) Save(ctx context.Context, dir, filename string, w io.Writer) error {
+	gdi, err := gdm.currentInstance()
+	if err != nil {
+		return err
+	}
+	return gdi.save(dir, filename, w)
+}
+
+// Exports the received msgs of the current instance to be used by other tools (see godebug.ExportFormats). Relative filenames are relative to dir. Slow (stringifies all msgs), should not run in the UI goroutine.
+func (gdm *GoDebugManager) Export(ctx context.Context, dir, format, filename string, w io.Writer) error {
+	if err := godebug.CheckExportFormat(format); err != nil {
+		return err
+	}
+	gdi, err := gdm.currentInstance()
+	if err != nil {
+		return err
+	}
+	return gdi.export(dir, format, filename, w)
+}
+
+// Snapshot of the current instance (the lock is not kept during long operations).
+func (gdm *GoDebugManager) currentInstance() (*GoDebugInstance, error) {
+	gdm.gdi.Lock()
+	defer gdm.gdi.Unlock()
+	if gdm.gdi.gdi == nil {
+		return nil, fmt.Errorf("missing godebug instance")
+	}
+	return gdm.gdi.gdi, nil
+}
+
 //----------
 
 func (gdm *GoDebugManager) CancelAndClear() {
 	gdm.gdi.Lock()
 	defer gdm.gdi.Unlock()
 	gdm.cancelAndWaitAndClear2()
+	gdm.clearPrev_noLock() // stopped: don't keep the data alive for a diff
 }
 func (gdm *GoDebugManager) cancelAndWaitAndClear2() {
 	if gdm.gdi.gdi != nil {
 		gdm.gdi.cancel()
 		gdm.gdi.gdi.cancelAndWaitAndClear()
+		if di := gdm.gdi.gdi.di; di.hasFilesData() {
+			gdm.keepPrev_noLock(di)
+		}
 		gdm.gdi.gdi = nil
 	}
 }
@@ -127,6 +193,25 @@ func (gdm *GoDebugManager) Trace() error {
 	return gdm.gdi.gdi.trace()
 }
 
+func (gdm *GoDebugManager) Goroutines() error {
+	gdm.gdi.Lock()
+	defer gdm.gdi.Unlock()
+	if gdm.gdi.gdi == nil {
+		return fmt.Errorf("missing godebug instance")
+	}
+	return gdm.gdi.gdi.goroutines()
+}
+
+// Restricts the annotation stepping (prev/next/first/last) to one goroutine. A nil id clears the restriction.
+func (gdm *GoDebugManager) SelectGoroutine(id *debug.GoroutineId) error {
+	gdm.gdi.Lock()
+	defer gdm.gdi.Unlock()
+	if gdm.gdi.gdi == nil {
+		return fmt.Errorf("missing godebug instance")
+	}
+	return gdm.gdi.gdi.selectGoroutine(id)
+}
+
 //----------
 
 func (gdm *GoDebugManager) Printf(format string, args ...any) {
@@ -147,6 +232,11 @@ type GoDebugInstance struct {
 	di     *GDDataIndex
 
 	cmdWait sync.WaitGroup
+	cmd     struct { // set while running, allows writing to the program
+		sync.Mutex
+		cmd *godebug.Cmd
+	}
+	bpsSend sync.Mutex // keeps the order of the breakpoints sends
 }
 
 func newGoDebugInstance(ctx context.Context, gdm *GoDebugManager, erow *ERow, args []string) (*GoDebugInstance, error) {
@@ -198,6 +288,9 @@ func (gdi *GoDebugInstance) runCmd(ctx context.Context, erow *ERow, args []strin
 	cmd.Dir = erow.Info.Name()
 	cmd.Stdout = w
 	cmd.Stderr = w
+	cmd.StartBreakpoints = func(fd *debug.FilesDataMsg) []*debug.Breakpoint {
+		return gdi.gdm.protoBreakpoints(fd.Data)
+	}
 
 	ctx, cancelCause := context.WithCancelCause(ctx)
 
@@ -208,6 +301,8 @@ func (gdi *GoDebugInstance) runCmd(ctx context.Context, erow *ERow, args []strin
 	if done {
 		return nil
 	}
+	gdi.setCmd(cmd)
+	defer gdi.setCmd(nil)
 
 	go func() {
 		if err := gdi.messagesLoop(cmd); err != nil {
@@ -296,7 +391,10 @@ func (gdi *GoDebugInstance) annotationFind(s string) error {
 //----------
 
 func (gdi *GoDebugInstance) trace() error {
-	msgs := gdi.di.trace()
+	msgs, goId, ok := gdi.di.trace()
+	if !ok {
+		return fmt.Errorf("no selected annotation")
+	}
 
 	// build output
 	sb := strings.Builder{}
@@ -309,7 +407,115 @@ func (gdi *GoDebugInstance) trace() error {
 		sb.WriteString("\t" + u + "\n")
 	}
 
-	gdi.gdm.Printf("trace (%d entries):\n%v", len(msgs), sb.String())
+	gdi.gdm.Printf("trace (%d entries, goroutine %d):\n%v", len(msgs), goId, sb.String())
+	return nil
+}
+
+//----------
+
+func (gdi *GoDebugInstance) goroutines() error {
+	gs, filter, filterOn := gdi.di.goroutinesList()
+
+	// build output
+	sb := strings.Builder{}
+	for _, g := range gs {
+		first, last := g.msgs[0], g.msgs[len(g.msgs)-1]
+		afd := gdi.di.afds[last.offsetMsg.FileIndex]
+		loc := fmt.Sprintf("%v:o=%d", afd.Filename, last.offsetMsg.Offset)
+		mark := ""
+		if filterOn && g.id == filter {
+			mark = " (stepping)"
+		}
+		u := fmt.Sprintf("goroutine %d%s: %d msgs: #%d..#%d: %v", g.id, mark, len(g.msgs), first.arrivalIndex, last.arrivalIndex, loc)
+		sb.WriteString("\t" + u + "\n")
+	}
+
+	gdi.gdm.Printf("goroutines (%d entries):\n%v", len(gs), sb.String())
+	return nil
+}
+
+func (gdi *GoDebugInstance) selectGoroutine(id *debug.GoroutineId) error {
+	if err := gdi.di.selectGoroutine(id); err != nil {
+		return err
+	}
+	gdi.updateAnnotationsAndShowLine(nil, gdi.gdm.ed.GoodRowPos())
+	return nil
+}
+
+//----------
+
+func (gdi *GoDebugInstance) save(dir, filename string, w io.Writer) error {
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(dir, filename)
+	}
+	fdata, msgs, err := gdi.di.recordData()
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := godebug.WriteRecord(f, fdata, msgs); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "godebug: saved: %v (%d files, %d msgs)\n", filename, len(fdata.Data), len(msgs))
+	return nil
+}
+
+func (gdi *GoDebugInstance) export(dir, format, filename string, w io.Writer) error {
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(dir, filename)
+	}
+	fdata, msgs, err := gdi.di.exportData()
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := godebug.WriteExport(f, format, fdata, msgs); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "godebug: exported (%v): %v (%d msgs)\n", format, filename, len(msgs))
+	return nil
+}
+
+func (gdi *GoDebugInstance) load(dir, filename string, w io.Writer) error {
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(dir, filename)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fdata, msgs, err := godebug.ReadRecord(f)
+	if err != nil {
+		return fmt.Errorf("%v: %w", filename, err)
+	}
+
+	// files that don't match the recorded hash will show as edited (no annotations)
+	if u := godebug.CheckRecordFiles(fdata, dir); len(u) > 0 {
+		fmt.Fprintf(w, "godebug: files not found or not matching the recorded hash:\n\t%v\n", strings.Join(u, "\n\t"))
+	}
+
+	if err := gdi.di.handleFilesDataMsg(fdata); err != nil {
+		return err
+	}
+	if err := gdi.di.handleOffsetMsgs(msgs...); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "godebug: loaded: %v (%d files, %d msgs)\n", filename, len(fdata.Data), len(msgs))
 	return nil
 }
 
@@ -321,9 +527,10 @@ func (gdi *GoDebugInstance) printIndex(erow *ERow, annIndex, offset int) {
 		return
 	}
 	// build output
-	s := godebug.StringifyItemFull(msg.offsetMsg.Item)
+	s, refs := godebug.StringifyItemFullRefs(msg.offsetMsg.Item)
 	s2 := "\t" + s + "\n"
-	gdi.gdm.Printf("annotation: #%d\n%v", msg.arrivalIndex, s2)
+	gdi.gdm.Printf("annotation: #%d (goroutine %d)\n%v", msg.arrivalIndex, msg.offsetMsg.GoId, s2)
+	gdi.printFullValues(refs, msg.offsetMsg.GoId)
 }
 
 func (gdi *GoDebugInstance) printIndexAllPrevious(erow *ERow, annIndex, offset int) {
@@ -410,22 +617,39 @@ func (gdi *GoDebugInstance) handleMsg(msg any, cmd *godebug.Cmd) error {
 	case *debug.FilesDataMsg:
 		return gdi.di.handleFilesDataMsg(t)
 	case *debug.OffsetMsg:
-		return gdi.di.handleOffsetMsgs(t)
+		return gdi.handleOffsetMsgs(t)
 	case *debug.OffsetMsgs:
-		return gdi.di.handleOffsetMsgs(*t...)
+		return gdi.handleOffsetMsgs(*t...)
+	case *debug.StringifyMsg:
+		gdi.handleStringifyMsg(t)
+		return nil
+	case *debug.TestMsg:
+		gdi.di.handleTestMsg(t)
+		return nil
 	default:
 		return fmt.Errorf("unexpected msg: %T", msg)
 	}
 }
+func (gdi *GoDebugInstance) handleOffsetMsgs(msgs ...*debug.OffsetMsg) error {
+	if err := gdi.di.handleOffsetMsgs(msgs...); err != nil {
+		return err
+	}
+	for _, m := range gdi.di.newPauses() {
+		gdi.onPause(m)
+	}
+	return nil
+}
 
 //----------
 
 func (gdi *GoDebugInstance) updateAnnotations() {
+	gdi.di.updateDiff() // not in the UI goroutine (stringifies the new msgs)
 	gdi.gdm.ed.UI.RunOnUIGoRoutine(func() {
 		gdi.updateAnnotations2()
 	})
 }
 func (gdi *GoDebugInstance) updateAnnotationsAndShowLine(preferedERow *ERow, rowPos *ui.RowPos) {
+	gdi.di.updateDiff()
 	gdi.gdm.ed.UI.RunOnUIGoRoutine(func() {
 		// ensure that current arrival index line erow is open such that updateannotations can calculate the selected index, and the showselectedline will have that index to select
 		gdi.openArrivalIndexERow()
@@ -457,6 +681,7 @@ func (gdi *GoDebugInstance) clearAnnotations() {
 func (gdi *GoDebugInstance) clearInfoAnnotations2(info *ERowInfo) {
 	for _, erow := range info.ERows {
 		gdi.setAnnotations(erow, -1, nil)
+		erow.Row.TextArea.SetAnnotationsColorOps(nil)
 	}
 }
 
@@ -478,10 +703,24 @@ func (gdi *GoDebugInstance) updateInfoAnnotations2(info *ERowInfo) {
 		return
 	}
 
+	if hits, n, ok := gdi.di.heatmapHits(info.Name()); ok {
+		for _, erow := range info.ERows {
+			gdi.setHeatmap(erow, hits, n)
+		}
+		return
+	}
+
+	diffHits, diffOn := gdi.di.diffHits(info.Name())
+
 	// set annotations into opened (existing) erows
 	// Note: the current selected debug line might not have an open erow (ex: when auto increased to match the lastarrivalindex).
 	for _, erow := range info.ERows {
 		gdi.setAnnotations(erow, selMsgIndex, entries)
+		if diffOn {
+			gdi.setDiffColorOps(erow, diffHits)
+		} else {
+			erow.Row.TextArea.SetAnnotationsColorOps(nil)
+		}
 	}
 }
 
@@ -558,6 +797,25 @@ type GDDataIndex struct {
 	filesEdited map[int]bool               // [fileindex]
 	filesIndexM map[string]int             // [name]fileindex
 
+	goroutines map[debug.GoroutineId]*GDGoroutine
+	paused     struct { // exec side goroutines paused at a breakpoint
+		m      map[debug.GoroutineId]*debug.OffsetMsg
+		order  []debug.GoroutineId // pause order (last is the most recent)
+		notify []*debug.OffsetMsg  // pauses not yet notified
+	}
+	goFilter struct { // restricts stepping to one goroutine
+		on bool
+		id debug.GoroutineId
+	}
+	tests struct { // "GoDebug test" tests and subtests
+		list []*GDTest
+		byGo map[debug.GoroutineId]*GDTest
+	}
+	heatmap bool    // view mode: show hit counts instead of values
+	diff    *GDDiff // view mode: differences with the previous run
+
+	fullValues map[debug.ValueRef]*GDFullValue // replies (or pending requests) of the full values of cut values
+
 	resetCount       int // number of resets to number msgs
 	lastArrivalIndex int
 	selected         struct {
@@ -574,6 +832,9 @@ func NewGDDataIndex(gdi *GoDebugInstance) *GDDataIndex {
 	di := &GDDataIndex{gdi: gdi}
 	di.filesIndexM = map[string]int{}
 	di.filesEdited = map[int]bool{}
+	di.goroutines = map[debug.GoroutineId]*GDGoroutine{}
+	di.fullValues = map[debug.ValueRef]*GDFullValue{}
+	di.resetTests_noLock()
 	di.resetArrivalIndex()
 	return di
 }
@@ -586,10 +847,7 @@ func (di *GDDataIndex) FilesIndex(name string) (int, bool) {
 	return v, ok
 }
 func (di *GDDataIndex) FilesIndexKey(name string) string {
-	if di.gdi.gdm.ed.FsCaseInsensitive {
-		name = strings.ToLower(name)
-	}
-	return name
+	return di.gdi.gdm.filenameKey(name)
 }
 
 //----------
@@ -608,6 +866,7 @@ func (di *GDDataIndex) reset2() {
 		u := NewGDFileMsgs(n)
 		*f = *u
 	}
+	di.goroutines = map[debug.GoroutineId]*GDGoroutine{}
 }
 func (di *GDDataIndex) resetArrivalIndex() {
 	di.lastArrivalIndex = -1
@@ -621,6 +880,12 @@ func (di *GDDataIndex) handleFilesDataMsg(fdm *debug.FilesDataMsg) error {
 	defer di.Unlock()
 
 	di.reset2()
+	di.goFilter.on = false // new run, ids are not related
+	di.resetPaused_noLock()
+	di.diff = nil // new run, the diff was with the previous data
+	di.heatmap = false
+	di.fullValues = map[debug.ValueRef]*GDFullValue{} // new run, refs are not related
+	di.resetTests_noLock()
 
 	di.afds = fdm.Data
 	// index filenames
@@ -658,6 +923,11 @@ func (di *GDDataIndex) handleOffsetMsg_noLock(u *debug.OffsetMsg) error {
 	if int(u.FileIndex) >= l1 {
 		return fmt.Errorf("bad file index: %v len=%v", u.FileIndex, l1)
 	}
+	// break msgs are not annotations
+	if _, ok := u.Item.(*debug.ItemBreak); ok {
+		di.addPaused_noLock(u)
+		return nil
+	}
 	// check index
 	l2 := len(di.files[int(u.FileIndex)].msgs)
 	if int(u.MsgIndex) >= l2 {
@@ -670,8 +940,21 @@ func (di *GDDataIndex) handleOffsetMsg_noLock(u *debug.OffsetMsg) error {
 	w := &di.files[u.FileIndex].msgs[u.MsgIndex].arrivals
 	*w = append(*w, lm)
 
+	// per goroutine arrival order
+	g, ok := di.goroutines[u.GoId]
+	if !ok {
+		g = &GDGoroutine{id: u.GoId}
+		di.goroutines[u.GoId] = g
+	}
+	prevLast := g.lastArrivalIndex()
+	g.msgs = append(g.msgs, lm)
+
 	// auto update selected index if at last position
-	if di.selected.arrivalIndex == di.lastArrivalIndex-1 {
+	if di.goFilter.on {
+		if u.GoId == di.goFilter.id && di.selected.arrivalIndex == prevLast {
+			di.selected.arrivalIndex = di.lastArrivalIndex
+		}
+	} else if di.selected.arrivalIndex == di.lastArrivalIndex-1 {
 		di.selected.arrivalIndex = di.lastArrivalIndex
 	}
 
@@ -814,6 +1097,12 @@ func (di *GDDataIndex) msgIndexFileMsg_noLock(filename string, msgIndex int) (*G
 func (di *GDDataIndex) selectFirst() error {
 	di.Lock()
 	defer di.Unlock()
+	if di.diff != nil {
+		return di.selectDiffStep_noLock(gdStepFirst)
+	}
+	if di.goFilter.on {
+		return di.selectGoroutineStep_noLock(gdStepFirst)
+	}
 	if di.lastArrivalIndex < 0 {
 		return fmt.Errorf("no indexes arrived yet")
 	}
@@ -827,6 +1116,12 @@ func (di *GDDataIndex) selectFirst() error {
 func (di *GDDataIndex) selectLast() error {
 	di.Lock()
 	defer di.Unlock()
+	if di.diff != nil {
+		return di.selectDiffStep_noLock(gdStepLast)
+	}
+	if di.goFilter.on {
+		return di.selectGoroutineStep_noLock(gdStepLast)
+	}
 	if di.lastArrivalIndex < 0 {
 		return fmt.Errorf("no indexes arrived yet")
 	}
@@ -840,6 +1135,12 @@ func (di *GDDataIndex) selectLast() error {
 func (di *GDDataIndex) selectPrev() error {
 	di.Lock()
 	defer di.Unlock()
+	if di.diff != nil {
+		return di.selectDiffStep_noLock(gdStepPrev)
+	}
+	if di.goFilter.on {
+		return di.selectGoroutineStep_noLock(gdStepPrev)
+	}
 	if di.lastArrivalIndex < 0 {
 		return fmt.Errorf("no indexes arrived yet")
 	}
@@ -857,6 +1158,12 @@ func (di *GDDataIndex) selectPrev() error {
 func (di *GDDataIndex) selectNext() error {
 	di.Lock()
 	defer di.Unlock()
+	if di.diff != nil {
+		return di.selectDiffStep_noLock(gdStepNext)
+	}
+	if di.goFilter.on {
+		return di.selectGoroutineStep_noLock(gdStepNext)
+	}
 	if di.lastArrivalIndex < 0 {
 		return fmt.Errorf("no indexes arrived yet")
 	}
@@ -873,6 +1180,154 @@ func (di *GDDataIndex) selectNext() error {
 
 //----------
 
+type gdStep int
+
+const (
+	gdStepFirst gdStep = iota
+	gdStepLast
+	gdStepPrev
+	gdStepNext
+)
+
+func (di *GDDataIndex) selectGoroutineStep_noLock(step gdStep) error {
+	g, ok := di.goroutines[di.goFilter.id]
+	if !ok || len(g.msgs) == 0 {
+		return fmt.Errorf("no indexes arrived yet for goroutine %v", di.goFilter.id)
+	}
+	sel := di.selected.arrivalIndex
+	k := 0
+	switch step {
+	case gdStepFirst:
+		k = 0
+	case gdStepLast:
+		k = len(g.msgs) - 1
+	case gdStepPrev:
+		if sel < 0 { // not selected yet
+			k = len(g.msgs) - 1
+			break
+		}
+		k = g.search(sel) - 1 // last below selected
+		if k < 0 {
+			return fmt.Errorf("already at first index of goroutine %v", g.id)
+		}
+	case gdStepNext:
+		if sel < 0 { // not selected yet
+			k = len(g.msgs) - 1
+			break
+		}
+		k = g.search(sel + 1) // first above selected
+		if k >= len(g.msgs) {
+			return fmt.Errorf("already at last index of goroutine %v", g.id)
+		}
+	}
+	if g.msgs[k].arrivalIndex == sel {
+		return fmt.Errorf("already at index %v of goroutine %v", sel, g.id)
+	}
+	di.selected.arrivalIndex = g.msgs[k].arrivalIndex
+	return nil
+}
+
+//----------
+
+// A nil id clears the stepping restriction. Otherwise, the selection moves to the goroutine msg at (or before) the current selection.
+func (di *GDDataIndex) selectGoroutine(id *debug.GoroutineId) error {
+	di.Lock()
+	defer di.Unlock()
+	if id == nil {
+		di.goFilter.on = false
+		return nil
+	}
+	g, ok := di.goroutines[*id]
+	if !ok || len(g.msgs) == 0 {
+		return fmt.Errorf("goroutine not found: %v", *id)
+	}
+	di.goFilter.on = true
+	di.goFilter.id = *id
+
+	k := len(g.msgs) - 1
+	if sel := di.selected.arrivalIndex; sel >= 0 {
+		k = g.search(sel+1) - 1 // last at or below selected
+		if k < 0 {
+			k = 0
+		}
+	}
+	di.selected.arrivalIndex = g.msgs[k].arrivalIndex
+	return nil
+}
+
+// Sorted by id.
+func (di *GDDataIndex) goroutinesList() ([]*GDGoroutine, debug.GoroutineId, bool) {
+	di.RLock()
+	defer di.RUnlock()
+	res := []*GDGoroutine{}
+	for _, g := range di.goroutines {
+		u := *g // copy, msgs slice only grows
+		res = append(res, &u)
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].id < res[b].id
+	})
+	return res, di.goFilter.id, di.goFilter.on
+}
+
+// Files data and the msgs in arrival order.
+func (di *GDDataIndex) recordData() (*debug.FilesDataMsg, []*debug.OffsetMsg, error) {
+	di.RLock()
+	defer di.RUnlock()
+	return di.recordData_noLock()
+}
+func (di *GDDataIndex) recordData_noLock() (*debug.FilesDataMsg, []*debug.OffsetMsg, error) {
+	fdata, u, err := di.arrivalMsgs_noLock()
+	if err != nil {
+		return nil, nil, err
+	}
+	msgs := make([]*debug.OffsetMsg, len(u))
+	for i, m := range u {
+		msgs[i] = m.offsetMsg
+	}
+	return fdata, msgs, nil
+}
+func (di *GDDataIndex) exportData() (*debug.FilesDataMsg, []*godebug.ArrivalMsg, error) {
+	di.RLock()
+	defer di.RUnlock()
+	fdata, u, err := di.arrivalMsgs_noLock()
+	if err != nil {
+		return nil, nil, err
+	}
+	msgs := make([]*godebug.ArrivalMsg, len(u))
+	for i, m := range u {
+		msgs[i] = &godebug.ArrivalMsg{Arrival: m.arrivalIndex, Msg: m.offsetMsg}
+	}
+	return fdata, msgs, nil
+}
+
+// Msgs of all goroutines sorted by arrival.
+func (di *GDDataIndex) arrivalMsgs_noLock() (*debug.FilesDataMsg, []*GDOffsetMsg, error) {
+	if di.afds == nil {
+		return nil, nil, fmt.Errorf("no files data received yet")
+	}
+	u := []*GDOffsetMsg{}
+	for _, g := range di.goroutines {
+		u = append(u, g.msgs...)
+	}
+	sort.Slice(u, func(a, b int) bool {
+		return u[a].arrivalIndex < u[b].arrivalIndex
+	})
+	return &debug.FilesDataMsg{Data: di.afds}, u, nil
+}
+
+func (di *GDDataIndex) arrivalMsg_noLock(arrivalIndex int) (*GDOffsetMsg, *GDGoroutine, bool) {
+	for _, g := range di.goroutines {
+		k := g.search(arrivalIndex)
+		if k < len(g.msgs) && g.msgs[k].arrivalIndex == arrivalIndex {
+			return g.msgs[k], g, true
+		}
+	}
+	return nil, nil, false
+}
+
+//----------
+
 func (di *GDDataIndex) findSelectedAndUpdateAnnEntries(info *ERowInfo) (entries *drawutil.AnnotationGroup, selMsgIndex int, edited bool, fileFound bool) {
 	di.Lock()
 	defer di.Unlock()
@@ -997,34 +1452,43 @@ func (di *GDDataIndex) filenameIsIndexed(filename string) bool {
 
 //----------
 
-func (di *GDDataIndex) trace() []*GDOffsetMsg {
+// Current msgs of the selected msg goroutine: the selected msg and the call lines that are still holding.
+func (di *GDDataIndex) trace() ([]*GDOffsetMsg, debug.GoroutineId, bool) {
 	di.RLock()
 	defer di.RUnlock()
 
-	res := []*GDOffsetMsg{}
-
 	arrivalIndex := di.selected.arrivalIndex
+	_, g, ok := di.arrivalMsg_noLock(arrivalIndex)
+	if !ok {
+		return nil, 0, false
+	}
 
-	// all files, all lines, if currently holding, keep it; check arrival
-	for _, f := range di.files {
-		for _, m := range f.msgs {
-			idx, eq, found := m.findIndex(arrivalIndex)
-			if !found {
-				continue
-			}
-			om := m.arrivals[idx]
-			// current line
-			if eq {
-				res = append(res, om)
-				continue
-			}
-			// call lines
-			switch om.offsetMsg.Item.(type) {
-			case *debug.ItemCallEnter,
-				*debug.ItemUnaryEnter,
-				*debug.ItemSend:
-				res = append(res, om)
-			}
+	// last msg of each file line, up to the selected msg
+	type key struct {
+		fileIndex debug.AfdFileIndex
+		msgIndex  debug.AfdMsgIndex
+	}
+	last := map[key]*GDOffsetMsg{}
+	for _, om := range g.msgs {
+		if om.arrivalIndex > arrivalIndex {
+			break
+		}
+		last[key{om.offsetMsg.FileIndex, om.offsetMsg.MsgIndex}] = om
+	}
+
+	res := []*GDOffsetMsg{}
+	for _, om := range last {
+		// current line
+		if om.arrivalIndex == arrivalIndex {
+			res = append(res, om)
+			continue
+		}
+		// call lines
+		switch om.offsetMsg.Item.(type) {
+		case *debug.ItemCallEnter,
+			*debug.ItemUnaryEnter,
+			*debug.ItemSend:
+			res = append(res, om)
 		}
 	}
 
@@ -1032,7 +1496,7 @@ func (di *GDDataIndex) trace() []*GDOffsetMsg {
 		return res[a].arrivalIndex < res[b].arrivalIndex
 	})
 
-	return res
+	return res, g.id, true
 }
 
 //----------
@@ -1118,6 +1582,29 @@ func (u *GDMsg) findIndex(arrivalIndex int) (int, bool, bool) {
 //----------
 //----------
 
+// Msgs of one goroutine in arrival order.
+type GDGoroutine struct {
+	id   debug.GoroutineId
+	msgs []*GDOffsetMsg
+}
+
+// Index of the first msg with arrival index >= arrivalIndex.
+func (g *GDGoroutine) search(arrivalIndex int) int {
+	return sort.Search(len(g.msgs), func(i int) bool {
+		return g.msgs[i].arrivalIndex >= arrivalIndex
+	})
+}
+func (g *GDGoroutine) lastArrivalIndex() int {
+	if len(g.msgs) == 0 {
+		return -1
+	}
+	return g.msgs[len(g.msgs)-1].arrivalIndex
+}
+
+//----------
+//----------
+//----------
+
 type GDOffsetMsg struct {
 	arrivalIndex int
 	resetIndex   int
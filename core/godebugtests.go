@@ -0,0 +1,136 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+// Lists the tests (and subtests) that were executed ("GoDebug test").
+func (gdm *GoDebugManager) Tests() error {
+	gdm.gdi.Lock()
+	defer gdm.gdi.Unlock()
+	if gdm.gdi.gdi == nil {
+		return fmt.Errorf("missing godebug instance")
+	}
+	return gdm.gdi.gdi.tests()
+}
+
+// Restricts the annotation stepping (prev/next/first/last) to the msgs of one test. An empty name clears the restriction.
+func (gdm *GoDebugManager) SelectTest(name string) error {
+	gdm.gdi.Lock()
+	defer gdm.gdi.Unlock()
+	if gdm.gdi.gdi == nil {
+		return fmt.Errorf("missing godebug instance")
+	}
+	gdi := gdm.gdi.gdi
+	if name == "" {
+		return gdi.selectGoroutine(nil)
+	}
+	t, ok := gdi.di.findTest(name)
+	if !ok {
+		return fmt.Errorf("test not found: %v", name)
+	}
+	if err := gdi.selectGoroutine(&t.goId); err != nil {
+		return fmt.Errorf("test %v: %w", name, err)
+	}
+	gdm.Printf("stepping test: %v (goroutine %d)", t.name, t.goId)
+	return nil
+}
+
+//----------
+
+func (gdi *GoDebugInstance) tests() error {
+	ts, filter, filterOn := gdi.di.testsList()
+	if len(ts) == 0 {
+		return fmt.Errorf("no tests executed (use \"GoDebug test\")")
+	}
+
+	// build output
+	sb := strings.Builder{}
+	for _, t := range ts {
+		mark := ""
+		if filterOn && t.goId == filter {
+			mark = " (stepping)"
+		}
+		u := fmt.Sprintf("%v%s: %v: %d msgs", t.name, mark, t.status(), t.nMsgs)
+		sb.WriteString("\t" + u + "\n")
+	}
+
+	gdi.gdm.Printf("tests (%d entries):\n%v", len(ts), sb.String())
+	return nil
+}
+
+//----------
+//----------
+//----------
+
+// A test (or subtest) runs in its own goroutine, its msgs are the goroutine msgs.
+type GDTest struct {
+	name   string
+	goId   debug.GoroutineId
+	done   bool
+	failed bool
+	nMsgs  int // set when listing
+}
+
+func (t *GDTest) status() string {
+	switch {
+	case !t.done:
+		return "running"
+	case t.failed:
+		return "fail"
+	default:
+		return "pass"
+	}
+}
+
+//----------
+
+func (di *GDDataIndex) handleTestMsg(m *debug.TestMsg) {
+	di.Lock()
+	defer di.Unlock()
+	t, ok := di.tests.byGo[m.GoId]
+	if !ok {
+		t = &GDTest{name: m.Name, goId: m.GoId}
+		di.tests.byGo[m.GoId] = t
+		di.tests.list = append(di.tests.list, t)
+	}
+	t.done = m.Done
+	t.failed = m.Failed
+}
+
+// In start order.
+func (di *GDDataIndex) testsList() ([]*GDTest, debug.GoroutineId, bool) {
+	di.RLock()
+	defer di.RUnlock()
+	res := []*GDTest{}
+	for _, t := range di.tests.list {
+		u := *t // copy
+		if g, ok := di.goroutines[t.goId]; ok {
+			u.nMsgs = len(g.msgs)
+		}
+		res = append(res, &u)
+	}
+	return res, di.goFilter.id, di.goFilter.on
+}
+
+// The last run of the test with the name (ex: "-count=2").
+func (di *GDDataIndex) findTest(name string) (*GDTest, bool) {
+	di.RLock()
+	defer di.RUnlock()
+	for i := len(di.tests.list) - 1; i >= 0; i-- {
+		t := di.tests.list[i]
+		if t.name == name {
+			u := *t // copy
+			return &u, true
+		}
+	}
+	return nil, false
+}
+
+func (di *GDDataIndex) resetTests_noLock() {
+	di.tests.list = nil
+	di.tests.byGo = map[debug.GoroutineId]*GDTest{}
+}
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+func TestGDTests1(t *testing.T) {
+	gdm := &GoDebugManager{ed: &Editor{}}
+	di := NewGDDataIndex(&GoDebugInstance{gdm: gdm})
+	di.handleTestMsg(&debug.TestMsg{GoId: 5, Name: "TestA"})
+	di.handleTestMsg(&debug.TestMsg{GoId: 6, Name: "TestA/s1"})
+	di.handleTestMsg(&debug.TestMsg{GoId: 6, Name: "TestA/s1", Done: true, Failed: true})
+	di.handleTestMsg(&debug.TestMsg{GoId: 9, Name: "TestA/s1"}) // -count=2
+
+	ts, _, _ := di.testsList()
+	if len(ts) != 3 {
+		t.Fatal(len(ts))
+	}
+	if ts[0].status() != "running" || ts[1].status() != "fail" {
+		t.Fatal(ts[0].status(), ts[1].status())
+	}
+	if u, ok := di.findTest("TestA/s1"); !ok || u.goId != 9 {
+		t.Fatal(u, ok)
+	}
+	if _, ok := di.findTest("TestB"); ok {
+		t.Fatal("found")
+	}
+}
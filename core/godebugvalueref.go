@@ -0,0 +1,70 @@
+package core
+
+import (
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+// Prints the full values already received, and requests the others from the running program (only once per value). The program only answers while the goroutine is paused.
+func (gdi *GoDebugInstance) printFullValues(refs []debug.ValueRef, goId debug.GoroutineId) {
+	for _, ref := range refs {
+		fv, ok := gdi.di.requestFullValue(ref)
+		if ok {
+			if !fv.pending {
+				gdi.gdm.Printf("full value: ref=%d\n\t%v\n", ref, fv.str)
+			}
+			continue
+		}
+		rsm := &debug.ReqStringifyMsg{Ref: ref, GoId: goId}
+		if err := gdi.protoWrite(rsm); err != nil {
+			gdi.di.clearFullValue(ref) // allow retrying
+			gdi.gdm.Printf("full value: ref=%d: not available: %v", ref, err)
+		}
+	}
+}
+
+func (gdi *GoDebugInstance) handleStringifyMsg(sm *debug.StringifyMsg) {
+	if sm.Err != "" {
+		gdi.di.clearFullValue(sm.Ref) // allow retrying (ex: when paused)
+		gdi.gdm.Printf("full value: ref=%d: %v", sm.Ref, sm.Err)
+		return
+	}
+	gdi.di.setFullValue(sm.Ref, sm.Str)
+	s := ""
+	if sm.Cut {
+		s = " (still cut)"
+	}
+	gdi.gdm.Printf("full value: ref=%d%v\n\t%v\n", sm.Ref, s, sm.Str)
+}
+
+//----------
+//----------
+//----------
+
+type GDFullValue struct {
+	str     string
+	pending bool // requested, waiting for the reply
+}
+
+// Returns the known value (or pending request), otherwise marks the value as requested and returns false.
+func (di *GDDataIndex) requestFullValue(ref debug.ValueRef) (*GDFullValue, bool) {
+	di.Lock()
+	defer di.Unlock()
+	if fv, ok := di.fullValues[ref]; ok {
+		return fv, true
+	}
+	di.fullValues[ref] = &GDFullValue{pending: true}
+	return nil, false
+}
+
+func (di *GDDataIndex) clearFullValue(ref debug.ValueRef) {
+	di.Lock()
+	defer di.Unlock()
+	delete(di.fullValues, ref)
+}
+
+// Keeps the value to be available after the program exits.
+func (di *GDDataIndex) setFullValue(ref debug.ValueRef, s string) {
+	di.Lock()
+	defer di.Unlock()
+	di.fullValues[ref] = &GDFullValue{str: s}
+}
@@ -0,0 +1,288 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"strings"
+
+	"github.com/jmigpin/editor/core/godebug"
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+// Lists the recorded values of a variable or selector (ex: "s.count") in arrival order. The values come from the annotated statements that assign to the expression (assignments, inc/dec, range key/value). Reads the annotated files, should not run in the UI goroutine.
+func (gdm *GoDebugManager) Watch(expr string) (string, error) {
+	gdm.gdi.Lock()
+	gdi := gdm.gdi.gdi
+	gdm.gdi.Unlock()
+	if gdi == nil {
+		return "", fmt.Errorf("missing godebug instance")
+	}
+	return gdi.watch(expr)
+}
+
+//----------
+
+func (gdi *GoDebugInstance) watch(expr string) (string, error) {
+	key, err := watchExprKey(expr)
+	if err != nil {
+		return "", err
+	}
+	wd, err := gdi.di.watchData()
+	if err != nil {
+		return "", err
+	}
+
+	// parse only the annotated files that received msgs (as they were annotated)
+	files := map[debug.AfdFileIndex]*GDWatchFile{}
+	skipped := []string{}
+	for _, afd := range wd.afds {
+		if !wd.received[afd.FileIndex] {
+			continue
+		}
+		src, err := os.ReadFile(afd.Filename)
+		if err != nil || wd.edited[afd.FileIndex] || !bytes.Equal(godebug.SourceHash(src), afd.FileHash) {
+			skipped = append(skipped, afd.Filename)
+			continue
+		}
+		wf, err := NewGDWatchFile(afd.Filename, src, key)
+		if err != nil {
+			skipped = append(skipped, afd.Filename)
+			continue
+		}
+		if len(wf.stmts) > 0 {
+			files[afd.FileIndex] = wf
+		}
+	}
+
+	// resolve the annotated offsets of the received msgs
+	sb := strings.Builder{}
+	n := 0
+	for i, m := range wd.msgs {
+		wf, ok := files[m.FileIndex]
+		if !ok {
+			continue
+		}
+		v, ok := wf.value(int(m.Offset), m.Item)
+		if !ok {
+			continue
+		}
+		n++
+		loc := fmt.Sprintf("%v:o=%d", wd.afds[m.FileIndex].Filename, m.Offset)
+		fmt.Fprintf(&sb, "#%d: %v: %v\n", i, v, loc)
+	}
+
+	s := fmt.Sprintf("watch: %v (%d values)\n", key, n)
+	if len(skipped) > 0 {
+		s += fmt.Sprintf("files not searched (edited or changed since annotated): %v\n", strings.Join(skipped, ", "))
+	}
+	return s + sb.String(), nil
+}
+
+//----------
+
+// Snapshot of the data index used by the watch.
+type gdWatchData struct {
+	afds     []*debug.AnnotatorFileData
+	msgs     []*debug.OffsetMsg // arrival order
+	received map[debug.AfdFileIndex]bool
+	edited   map[debug.AfdFileIndex]bool
+}
+
+func (di *GDDataIndex) watchData() (*gdWatchData, error) {
+	di.RLock()
+	defer di.RUnlock()
+	fdata, msgs, err := di.recordData_noLock()
+	if err != nil {
+		return nil, err
+	}
+	wd := &gdWatchData{
+		afds:     fdata.Data,
+		msgs:     msgs,
+		received: map[debug.AfdFileIndex]bool{},
+		edited:   map[debug.AfdFileIndex]bool{},
+	}
+	for _, m := range msgs {
+		wd.received[m.FileIndex] = true
+	}
+	for findex, edited := range di.filesEdited {
+		wd.edited[debug.AfdFileIndex(findex)] = edited
+	}
+	return wd, nil
+}
+
+//----------
+//----------
+//----------
+
+// Statements of an annotated file that assign to a watched expression.
+type GDWatchFile struct {
+	stmts    []*gdWatchStmt
+	funcLits [][2]int // ranges of func literals (their msgs belong to their own statements)
+}
+
+type gdWatchStmt struct {
+	start, end int // msgs offsets range
+	kind       gdWatchKind
+	index      int // index of the watched expr in the lhs
+	n          int // number of lhs exprs
+}
+
+type gdWatchKind int
+
+const (
+	gdwkAssign gdWatchKind = iota
+	gdwkIncDec
+	gdwkRange
+)
+
+func NewGDWatchFile(filename string, src []byte, key string) (*GDWatchFile, error) {
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	offset := func(p token.Pos) int {
+		return fset.Position(p).Offset
+	}
+	wf := &GDWatchFile{}
+	add := func(start, end token.Pos, kind gdWatchKind, lhs []ast.Expr) {
+		for i, e := range lhs {
+			if e != nil && types.ExprString(e) == key {
+				ws := &gdWatchStmt{start: offset(start), end: offset(end), kind: kind, index: i, n: len(lhs)}
+				wf.stmts = append(wf.stmts, ws)
+				return
+			}
+		}
+	}
+	ast.Inspect(astFile, func(node ast.Node) bool {
+		switch t := node.(type) {
+		case *ast.AssignStmt:
+			add(t.Pos(), t.End(), gdwkAssign, t.Lhs)
+		case *ast.IncDecStmt:
+			add(t.Pos(), t.End(), gdwkIncDec, []ast.Expr{t.X})
+		case *ast.RangeStmt:
+			lhs := []ast.Expr{t.Key}
+			if t.Value != nil {
+				lhs = append(lhs, t.Value)
+			}
+			if t.Key != nil {
+				add(t.Key.Pos(), t.X.Pos(), gdwkRange, lhs) // msgs of the body have their own statements
+			}
+		case *ast.FuncLit:
+			wf.funcLits = append(wf.funcLits, [2]int{offset(t.Pos()), offset(t.End())})
+		}
+		return true
+	})
+	return wf, nil
+}
+
+// Value of the watched expression if the msg was sent by one of the statements.
+func (wf *GDWatchFile) value(offset int, item debug.Item) (string, bool) {
+	ws, ok := wf.stmt(offset)
+	if !ok {
+		return "", false
+	}
+	switch ws.kind {
+	case gdwkIncDec:
+		if _, ok := item.(*debug.ItemList); ok {
+			return "", false
+		}
+		return watchItemValue(item)
+	default:
+		var list *debug.ItemList
+		switch t := item.(type) {
+		case *debug.ItemAssign:
+			list = t.Lhs // values after the assignment
+		case *debug.ItemList:
+			list = t
+			if len(t.List) == 1 && ws.n > 1 { // ex: "a, b := f()"
+				if u, ok := watchItemResult(t.List[0]).(*debug.ItemList); ok {
+					list = u
+				}
+			}
+		}
+		if list == nil || len(list.List) != ws.n {
+			return "", false
+		}
+		return watchItemValue(list.List[ws.index])
+	}
+}
+
+// Innermost statement with the offset.
+func (wf *GDWatchFile) stmt(offset int) (*gdWatchStmt, bool) {
+	var res *gdWatchStmt
+	for _, ws := range wf.stmts {
+		if offset < ws.start || offset >= ws.end {
+			continue
+		}
+		if res == nil || ws.end-ws.start < res.end-res.start {
+			res = ws
+		}
+	}
+	if res == nil {
+		return nil, false
+	}
+	for _, r := range wf.funcLits {
+		if r[0] >= res.start && offset >= r[0] && offset < r[1] {
+			return nil, false // offset is in a func literal inside the statement
+		}
+	}
+	return res, true
+}
+
+//----------
+
+func watchItemValue(item debug.Item) (string, bool) {
+	switch item.(type) {
+	case *debug.ItemCallEnter, *debug.ItemUnaryEnter:
+		return "", false
+	}
+	item = watchItemResult(item)
+	if item == nil {
+		return "", false
+	}
+	return godebug.StringifyItemFull(item), true
+}
+
+// Result value of the items that show the expression with the result (ex: "3=f()").
+func watchItemResult(item debug.Item) debug.Item {
+	switch t := item.(type) {
+	case *debug.ItemCall:
+		return t.Result
+	case *debug.ItemIndex:
+		return t.Result
+	case *debug.ItemSelector:
+		return t.Result
+	case *debug.ItemTypeAssert:
+		return t.Result
+	case *debug.ItemBinary:
+		return t.Result
+	case *debug.ItemUnary:
+		return t.Result
+	case *debug.ItemParen:
+		return watchItemResult(t.X)
+	}
+	return item
+}
+
+func watchExprKey(expr string) (string, error) {
+	e, err := parser.ParseExpr(strings.TrimSpace(expr))
+	if err != nil {
+		return "", fmt.Errorf("watch: %w", err)
+	}
+	for u := e; ; {
+		switch t := u.(type) {
+		case *ast.Ident:
+			return types.ExprString(e), nil
+		case *ast.SelectorExpr:
+			u = t.X
+			continue
+		}
+		return "", fmt.Errorf("watch: expecting variable or selector: %v", expr)
+	}
+}
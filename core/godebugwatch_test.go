@@ -0,0 +1,74 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+func TestGDWatch1(t *testing.T) {
+	src := `package main
+func main() {
+	s := &S{}
+	s.count = 3
+	s.count++
+	s.count += 2
+	a, s.count = f()
+	for _, s.count = range []int{7} {
+		_ = a
+	}
+	g := func() { s.count = 9 }
+	_ = g
+}
+`
+	wf, err := NewGDWatchFile("main.go", []byte(src), "s.count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	off := func(s string) int {
+		return strings.Index(src, s)
+	}
+	iv := func(s string) *debug.ItemValue {
+		return &debug.ItemValue{Str: s}
+	}
+	il := func(u ...debug.Item) *debug.ItemList {
+		return &debug.ItemList{List: u}
+	}
+
+	type entry struct {
+		offset int
+		item   debug.Item
+		res    string // "" if no value
+	}
+	w := []entry{
+		{off("&S{}"), il(iv("&S{}")), ""},
+		{off("3\n"), il(iv("3")), "3"},
+		{off("s.count++"), iv("4"), "4"},
+		{off("s.count += 2"), &debug.ItemAssign{Lhs: il(iv("6")), Rhs: il(iv("2"))}, "6"},
+		{off("f()"), &debug.ItemCallEnter{Fun: iv("f")}, ""},
+		{off("f()"), il(&debug.ItemCall{Result: il(iv("1"), iv("2"))}), "2"},
+		{off("_, s.count = range"), il(iv("0"), iv("7")), "7"},
+		{off("_ = a"), il(iv("1")), ""},
+		{off("9 }"), il(iv("9")), "9"},
+		{off("func()"), il(iv("f")), ""},
+	}
+	for i, e := range w {
+		v, ok := wf.value(e.offset, e.item)
+		if !ok {
+			v = ""
+		}
+		if v != e.res {
+			t.Fatalf("entry %d: expecting %q, got %q", i, e.res, v)
+		}
+	}
+}
+
+func TestGDWatchExprKey(t *testing.T) {
+	if k, err := watchExprKey(" req . Header "); err != nil || k != "req.Header" {
+		t.Fatal(k, err)
+	}
+	if _, err := watchExprKey("f()"); err == nil {
+		t.Fatal("expecting error")
+	}
+}
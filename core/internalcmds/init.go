@@ -65,6 +65,15 @@ func init() {
 	cmd(GoDebug, "GoDebug")
 	cmd(GoDebugFind, "GoDebugFind")
 	cmd(GoDebugTrace, "GoDebugTrace")
+	cmd(GoDebugHeatmap, "GoDebugHeatmap")
+	cmd(GoDebugGoroutines, "GoDebugGoroutines")
+	cmd(GoDebugGoroutine, "GoDebugGoroutine")
+	cmd(GoDebugTests, "GoDebugTests")
+	cmd(GoDebugTest, "GoDebugTest")
+	cmd(GoDebugWatch, "GoDebugWatch")
+	cmd(GoDebugBreak, "GoDebugBreak")
+	cmd(GoDebugContinue, "GoDebugContinue")
+	cmd(GoDebugStep, "GoDebugStep")
 
 	cmd(LSProtoCloseAll, "LsprotoCloseAll", "LSProtoCloseAll") // TODO: deprecate LSProtoCloseAll
 	cmd(LSProtoRename, "LsprotoRename")
@@ -72,6 +81,17 @@ func init() {
 	cmd(LSProtoImplementors, "LsprotoImplementors")
 	cmd(LSProtoCallHierarchyIncomingCalls, "LsprotoCallers", "LsprotoCallHierarchyIncomingCalls")
 	cmd(LSProtoCallHierarchyOutgoingCalls, "LsprotoCallees", "LsprotoCallHierarchyOutgoingCalls")
+	cmd(LSProtoTypeHierarchySupertypes, "LsprotoSupertypes", "LsprotoTypeHierarchySupertypes")
+	cmd(LSProtoTypeHierarchySubtypes, "LsprotoSubtypes", "LsprotoTypeHierarchySubtypes")
+	cmd(LSProtoTypeDefinition, "LsprotoTypeDefinition")
+	cmd(LSProtoDeclaration, "LsprotoDeclaration")
+	cmd(LSProtoDiagnostics, "LsprotoDiagnostics")
+	cmd(LSProtoFormat, "LsprotoFormat")
+	cmd(LSProtoCodeActions, "LsprotoCodeActions")
+	cmd(LSProtoSymbols, "LsprotoSymbols")
+	cmd(LSProtoWorkspaceSymbols, "LsprotoWorkspaceSymbols")
+	cmd(LSProtoTrace, "LsprotoTrace")
+	cmd(LSProtoStatus, "LsprotoStatus")
 
 	cmd(ColorTheme, "ColorTheme")
 	cmd(FontTheme, "FontTheme")
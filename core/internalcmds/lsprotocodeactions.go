@@ -0,0 +1,59 @@
+package internalcmds
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/core/lsproto"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func LSProtoCodeActions(args *core.InternalCmdArgs) error {
+	ed := args.Ed
+
+	erow, err := args.ERowOrErr()
+	if err != nil {
+		return err
+	}
+
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+
+	// cursor/selection range
+	ta := erow.Row.TextArea
+	offset, n := ta.CursorIndex(), 0
+	if a, b, ok := ta.Cursor().SelectionIndexes(); ok {
+		offset, n = a, b-a
+	}
+
+	// create new erow to run on
+	info := erow.Ed.ReadERowInfo(erow.Info.Dir())
+	erow2 := core.NewBasicERow(info, erow.Row.PosBelow())
+	iorw.Append(erow2.Row.Toolbar.RW(), []byte(" | Stop"))
+	erow2.Flash()
+
+	// NOTE: args0.Ctx will end at func exit
+
+	erow2.Exec.RunAsync(func(ctx context.Context, rw io.ReadWriter) error {
+		// NOTE: not running in UI goroutine here
+
+		mcas, err := ed.LSProtoMan.TextDocumentCodeActions(ctx, erow.Info.Name(), ta.RW(), offset, n)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(rw, "lsproto code actions:")
+		if len(mcas) == 0 {
+			fmt.Fprintf(rw, " no results\n")
+			return nil
+		}
+		str := lsproto.CodeActionsToString(mcas)
+		fmt.Fprintf(rw, "\n%v", str)
+		return nil
+	})
+
+	return nil
+}
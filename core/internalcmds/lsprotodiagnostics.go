@@ -0,0 +1,46 @@
+package internalcmds
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/core/lsproto"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func LSProtoDiagnostics(args *core.InternalCmdArgs) error {
+	ed := args.Ed
+
+	erow, err := args.ERowOrErr()
+	if err != nil {
+		return err
+	}
+
+	if erow.Info.IsSpecial() {
+		return fmt.Errorf("not a file or directory")
+	}
+
+	// create new erow to run on
+	info := erow.Ed.ReadERowInfo(erow.Info.Dir())
+	erow2 := core.NewBasicERow(info, erow.Row.PosBelow())
+	iorw.Append(erow2.Row.Toolbar.RW(), []byte(" | Stop"))
+	erow2.Flash()
+
+	erow2.Exec.RunAsync(func(ctx context.Context, rw io.ReadWriter) error {
+		// NOTE: not running in UI goroutine here
+
+		fdiags := ed.LSProtoMan.AllDiagnostics()
+		fmt.Fprintf(rw, "lsproto diagnostics:")
+		if len(fdiags) == 0 {
+			fmt.Fprintf(rw, " no results\n")
+			return nil
+		}
+		str := lsproto.DiagnosticsToString(fdiags, erow2.Info.Dir())
+		fmt.Fprintf(rw, "\n%v", str)
+		return nil
+	})
+
+	return nil
+}
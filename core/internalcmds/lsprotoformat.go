@@ -0,0 +1,87 @@
+package internalcmds
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// Formats the whole file, or the selection if present.
+func LSProtoFormat(args *core.InternalCmdArgs) error {
+	ed := args.Ed
+
+	erow, err := args.ERowOrErr()
+	if err != nil {
+		return err
+	}
+
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+
+	ta := erow.Row.TextArea
+	offset, n := 0, -1 // whole file
+	if a, b, ok := ta.Cursor().SelectionIndexes(); ok {
+		offset, n = a, b-a
+	}
+
+	// content to format (the edits only apply if it is not changed meanwhile)
+	b, err := iorw.ReadFullCopy(ta.RW())
+	if err != nil {
+		return err
+	}
+
+	// create new erow to run on
+	info := erow.Ed.ReadERowInfo(erow.Info.Dir())
+	erow2 := core.NewBasicERow(info, erow.Row.PosBelow())
+	iorw.Append(erow2.Row.Toolbar.RW(), []byte(" | Stop"))
+	erow2.Flash()
+
+	// NOTE: args0.Ctx will end at func exit
+
+	erow2.Exec.RunAsync(func(ctx context.Context, rw io.ReadWriter) error {
+		// NOTE: not running in UI goroutine here
+
+		rd := iorw.NewBytesReadWriterAt(b)
+		edits, err := ed.LSProtoMan.TextDocumentFormatting(ctx, erow.Info.Name(), rd, offset, n)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(rw, "lsproto format:")
+		if len(edits) == 0 {
+			fmt.Fprintf(rw, " no edits\n")
+			return nil
+		}
+
+		// apply edits as one undo group
+		errC := make(chan error, 1)
+		ed.UI.RunOnUIGoRoutine(func() {
+			b2, err := iorw.ReadFastFull(ta.RW())
+			if err != nil {
+				errC <- err
+				return
+			}
+			if !bytes.Equal(b, b2) {
+				errC <- fmt.Errorf("content changed while formatting")
+				return
+			}
+			errC <- core.LSProtoApplyTextEdits(ta.TextEdit, edits)
+		})
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errC:
+			if err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(rw, " %d edits applied\n", len(edits))
+		return nil
+	})
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package internalcmds
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/core/lsproto"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func LSProtoSymbols(args *core.InternalCmdArgs) error {
+	ed := args.Ed
+
+	erow, err := args.ERowOrErr()
+	if err != nil {
+		return err
+	}
+
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+
+	// create new erow to run on
+	info := erow.Ed.ReadERowInfo(erow.Info.Dir())
+	erow2 := core.NewBasicERow(info, erow.Row.PosBelow())
+	iorw.Append(erow2.Row.Toolbar.RW(), []byte(" | Stop"))
+	erow2.Flash()
+
+	erow2.Exec.RunAsync(func(ctx context.Context, rw io.ReadWriter) error {
+		// NOTE: not running in UI goroutine here
+
+		ta := erow.Row.TextArea
+		syms, err := ed.LSProtoMan.TextDocumentDocumentSymbol(ctx, erow.Info.Name(), ta.RW())
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(rw, "lsproto symbols:")
+		if len(syms) == 0 {
+			fmt.Fprintf(rw, " no results\n")
+			return nil
+		}
+		str := lsproto.DocumentSymbolsToString(erow.Info.Name(), syms, erow2.Info.Dir())
+		fmt.Fprintf(rw, "\n%v", str)
+		return nil
+	})
+
+	return nil
+}
+
+//----------
+
+func LSProtoWorkspaceSymbols(args *core.InternalCmdArgs) error {
+	ed := args.Ed
+
+	erow, err := args.ERowOrErr()
+	if err != nil {
+		return err
+	}
+
+	// the row filename is used to choose the lsp instance
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+
+	args2 := args.Part.Args[1:]
+	if len(args2) < 1 {
+		return fmt.Errorf("expecting query argument")
+	}
+	u := []string{}
+	for _, a := range args2 {
+		u = append(u, a.UnquotedString())
+	}
+	query := strings.Join(u, " ")
+
+	// create new erow to run on
+	info := erow.Ed.ReadERowInfo(erow.Info.Dir())
+	erow2 := core.NewBasicERow(info, erow.Row.PosBelow())
+	iorw.Append(erow2.Row.Toolbar.RW(), []byte(" | Stop"))
+	erow2.Flash()
+
+	erow2.Exec.RunAsync(func(ctx context.Context, rw io.ReadWriter) error {
+		// NOTE: not running in UI goroutine here
+
+		syms, err := ed.LSProtoMan.WorkspaceSymbol(ctx, erow.Info.Name(), query)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(rw, "lsproto workspace symbols (%q):", query)
+		if len(syms) == 0 {
+			fmt.Fprintf(rw, " no results\n")
+			return nil
+		}
+		str, err := lsproto.WorkspaceSymbolsToString(syms, erow2.Info.Dir())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(rw, "\n%v", str)
+		return nil
+	})
+
+	return nil
+}
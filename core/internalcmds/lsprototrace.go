@@ -0,0 +1,60 @@
+package internalcmds
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// Streams the lsp messages of a language instance. Language is the first argument, or the language of the row file.
+func LSProtoTrace(args *core.InternalCmdArgs) error {
+	ed := args.Ed
+
+	language := ""
+	if len(args.Part.Args) >= 2 {
+		language = args.Part.Args[1].UnquotedString()
+	} else {
+		erow, ok := args.ERow()
+		if !ok || !erow.Info.IsFileButNotDir() {
+			return fmt.Errorf("expecting language argument")
+		}
+		lang, err := ed.LSProtoMan.LangManager(erow.Info.Name())
+		if err != nil {
+			return err
+		}
+		language = lang.Reg.Language
+	}
+
+	erow2, isNew := core.ExistingERowOrNewBasic(ed, "+LsprotoTrace")
+	if isNew {
+		iorw.Append(erow2.Row.Toolbar.RW(), []byte(" | Stop"))
+	}
+	erow2.Flash()
+
+	erow2.Exec.RunAsync(func(ctx context.Context, rw io.ReadWriter) error {
+		// NOTE: not running in UI goroutine here
+
+		fmt.Fprintf(rw, "lsproto trace: %v\n", language)
+		return ed.LSProtoMan.Trace(ctx, language, rw)
+	})
+	return nil
+}
+
+func LSProtoStatus(args *core.InternalCmdArgs) error {
+	ed := args.Ed
+
+	erow2, _ := core.ExistingERowOrNewBasic(ed, "+LsprotoStatus")
+	erow2.Row.TextArea.SetBytesClearPos(nil)
+	erow2.Flash()
+
+	erow2.Exec.RunAsync(func(ctx context.Context, rw io.ReadWriter) error {
+		// NOTE: not running in UI goroutine here
+
+		fmt.Fprint(rw, ed.LSProtoMan.Status())
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,64 @@
+package internalcmds
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/core/lsproto"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func LSProtoTypeDefinition(args *core.InternalCmdArgs) error {
+	return lsprotoLocations(args, "type definition", args.Ed.LSProtoMan.TextDocumentTypeDefinition)
+}
+func LSProtoDeclaration(args *core.InternalCmdArgs) error {
+	return lsprotoLocations(args, "declaration", args.Ed.LSProtoMan.TextDocumentDeclaration)
+}
+
+func lsprotoLocations(args *core.InternalCmdArgs, title string, fn func(context.Context, string, iorw.ReaderAt, int) ([]*lsproto.Location, error)) error {
+	erow, err := args.ERowOrErr()
+	if err != nil {
+		return err
+	}
+
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+
+	// create new erow to run on
+	dir := filepath.Dir(erow.Info.Name())
+	info := erow.Ed.ReadERowInfo(dir)
+	erow2 := core.NewBasicERow(info, erow.Row.PosBelow())
+	iorw.Append(erow2.Row.Toolbar.RW(), []byte(" | Stop"))
+	erow2.Flash()
+
+	// NOTE: args0.Ctx will end at func exit
+
+	erow2.Exec.RunAsync(func(ctx context.Context, rw io.ReadWriter) error {
+		// NOTE: not running in UI goroutine here
+
+		ta := erow.Row.TextArea
+		locs, err := fn(ctx, erow.Info.Name(), ta.RW(), ta.CursorIndex())
+		if err != nil {
+			return err
+		}
+
+		// print locations
+		str, err := lsproto.LocationsToString(locs, erow2.Info.Dir())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(rw, "lsproto %s:", title)
+		if len(locs) == 0 {
+			fmt.Fprintf(rw, " no results\n")
+			return nil
+		}
+		fmt.Fprintf(rw, "\n%v", str)
+		return nil
+	})
+
+	return nil
+}
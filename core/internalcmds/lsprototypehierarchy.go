@@ -0,0 +1,59 @@
+package internalcmds
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/core/lsproto"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func LSProtoTypeHierarchySupertypes(args *core.InternalCmdArgs) error {
+	return lsprotoTypeHierarchyTypes(args, lsproto.SupertypesTht)
+}
+func LSProtoTypeHierarchySubtypes(args *core.InternalCmdArgs) error {
+	return lsprotoTypeHierarchyTypes(args, lsproto.SubtypesTht)
+}
+
+func lsprotoTypeHierarchyTypes(args *core.InternalCmdArgs, typ lsproto.TypeHierarchyType) error {
+	ed := args.Ed
+
+	erow, err := args.ERowOrErr()
+	if err != nil {
+		return err
+	}
+
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+
+	// create new erow to run on
+	dir := filepath.Dir(erow.Info.Name())
+	info := erow.Ed.ReadERowInfo(dir)
+	erow2 := core.NewBasicERow(info, erow.Row.PosBelow())
+	iorw.Append(erow2.Row.Toolbar.RW(), []byte(" | Stop"))
+	erow2.Flash()
+
+	// NOTE: args0.Ctx will end at func exit
+
+	erow2.Exec.RunAsync(func(ctx context.Context, rw io.ReadWriter) error {
+		// NOTE: not running in UI goroutine here
+
+		ta := erow.Row.TextArea
+		mtypes, err := ed.LSProtoMan.TypeHierarchyTypes(ctx, erow.Info.Name(), ta.RW(), ta.CursorIndex(), typ)
+		if err != nil {
+			return err
+		}
+		str, err := lsproto.ManagerTypeHierarchyTypesToString(mtypes, typ, erow2.Info.Dir())
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(rw, str)
+		return nil
+	})
+
+	return nil
+}
@@ -2,18 +2,23 @@ package internalcmds
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
 
 	"github.com/jmigpin/editor/core"
 	"github.com/jmigpin/editor/core/fswatcher"
 	"github.com/jmigpin/editor/core/godebug"
+	"github.com/jmigpin/editor/core/godebug/debug"
 	"github.com/jmigpin/editor/ui"
 	"github.com/jmigpin/editor/util/ctxutil"
 	"github.com/jmigpin/editor/util/fontutil"
 	"github.com/jmigpin/editor/util/iout"
+	"github.com/jmigpin/editor/util/iout/iorw"
 )
 
 //----------
@@ -248,11 +253,26 @@ func OpenExternal(args *core.InternalCmdArgs) error {
 func GoDebug(args *core.InternalCmdArgs) error {
 	args2 := args.Part.ArgsUnquoted()
 
+	// editor side only commands
+	if len(args2) >= 2 {
+		switch args2[1] {
+		case "save", "load":
+			return goDebugSaveLoad(args, args2[1:])
+		case "export":
+			return goDebugExport(args, args2[1:])
+		case "diff":
+			return args.Ed.GoDebug.ToggleDiff()
+		}
+	}
+
 	// special case: show help
 	cmd := godebug.NewCmd()
 	buf := &bytes.Buffer{}
 	cmd.Stderr = buf
 	if err := cmd.ParseFlagsOnce(args2[1:]); errors.Is(err, flag.ErrHelp) {
+		if len(args2) <= 2 { // commands usage (not a command help)
+			buf.WriteString(goDebugEditorUsage)
+		}
 		return fmt.Errorf("%w\n%v", err, buf.String())
 	}
 
@@ -263,6 +283,69 @@ func GoDebug(args *core.InternalCmdArgs) error {
 	return args.Ed.GoDebug.RunAsync(args.Ctx, erow, args2)
 }
 
+// Commands handled by the editor (not by godebug.Cmd).
+const goDebugEditorUsage = `Editor side commands:
+	save		save the current session data to a file
+	load		load a saved session data file, files are checked against the recorded hash
+	export	export the current session msgs to a file: -format=json|chrometrace
+	diff		toggle highlighting the annotations that differ from the previous run, stepping only through the first divergent msgs
+Examples:
+	GoDebug save session.gdrec
+	GoDebug load session.gdrec
+	GoDebug export -format=chrometrace trace.json
+`
+
+func goDebugSaveLoad(args *core.InternalCmdArgs, args2 []string) error {
+	if len(args2) != 2 {
+		return fmt.Errorf("usage: GoDebug %v <filename>", args2[0])
+	}
+	erow, err := args.ERowOrErr()
+	if err != nil {
+		return err
+	}
+	fn := args.Ed.GoDebug.Load
+	if args2[0] == "save" {
+		fn = args.Ed.GoDebug.Save
+	}
+	goDebugRunAsync(erow, func(ctx context.Context, dir string, rw io.ReadWriter) error {
+		return fn(ctx, dir, args2[1], rw)
+	})
+	return nil
+}
+
+func goDebugExport(args *core.InternalCmdArgs, args2 []string) error {
+	fs := flag.NewFlagSet(args2[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	format := fs.String("format", "json", "")
+	if err := fs.Parse(args2[1:]); err != nil || fs.NArg() != 1 {
+		return fmt.Errorf("usage: GoDebug export [-format=%v] <filename>", strings.Join(godebug.ExportFormats, "|"))
+	}
+	if err := godebug.CheckExportFormat(*format); err != nil {
+		return err
+	}
+	erow, err := args.ERowOrErr()
+	if err != nil {
+		return err
+	}
+	goDebugRunAsync(erow, func(ctx context.Context, dir string, rw io.ReadWriter) error {
+		return args.Ed.GoDebug.Export(ctx, dir, *format, fs.Arg(0), rw)
+	})
+	return nil
+}
+
+// Runs editor side commands that can be slow (file io, encoding) on a new erow, not in the UI goroutine.
+func goDebugRunAsync(erow *core.ERow, fn func(ctx context.Context, dir string, rw io.ReadWriter) error) {
+	info := erow.Ed.ReadERowInfo(erow.Info.Dir())
+	erow2 := core.NewBasicERow(info, erow.Row.PosBelow())
+	iorw.Append(erow2.Row.Toolbar.RW(), []byte(" | Stop"))
+	erow2.Flash()
+
+	erow2.Exec.RunAsync(func(ctx context.Context, rw io.ReadWriter) error {
+		// NOTE: not running in UI goroutine here
+		return fn(ctx, erow2.Info.Dir(), rw)
+	})
+}
+
 func GoDebugFind(args *core.InternalCmdArgs) error {
 	// TODO: erow needed?
 	//erow, err := args.ERowOrErr()
@@ -288,6 +371,110 @@ func GoDebugTrace(args *core.InternalCmdArgs) error {
 	return args.Ed.GoDebug.Trace()
 }
 
+func GoDebugHeatmap(args *core.InternalCmdArgs) error {
+	return args.Ed.GoDebug.ToggleHeatmap()
+}
+
+func GoDebugBreak(args *core.InternalCmdArgs) error {
+	erow, err := args.ERowOrErr()
+	if err != nil {
+		return err
+	}
+	return args.Ed.GoDebug.ToggleBreakpoint(erow)
+}
+
+func GoDebugContinue(args *core.InternalCmdArgs) error {
+	id, err := goDebugPausedId(args)
+	if err != nil {
+		return err
+	}
+	return args.Ed.GoDebug.Continue(false, id)
+}
+
+func GoDebugStep(args *core.InternalCmdArgs) error {
+	id, err := goDebugPausedId(args)
+	if err != nil {
+		return err
+	}
+	return args.Ed.GoDebug.Continue(true, id)
+}
+
+// Optional goroutine id (zero if not given).
+func goDebugPausedId(args *core.InternalCmdArgs) (debug.GoroutineId, error) {
+	a := args.Part.ArgsUnquoted()
+	if len(a) > 2 {
+		return 0, fmt.Errorf("expecting optional goroutine id")
+	}
+	if len(a) < 2 {
+		return 0, nil
+	}
+	u, err := strconv.ParseUint(a[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return debug.GoroutineId(u), nil
+}
+
+func GoDebugGoroutines(args *core.InternalCmdArgs) error {
+	return args.Ed.GoDebug.Goroutines()
+}
+
+func GoDebugGoroutine(args *core.InternalCmdArgs) error {
+	a := args.Part.ArgsUnquoted()
+	if len(a) != 2 {
+		return fmt.Errorf("expecting goroutine id or \"all\"")
+	}
+	if a[1] == "all" {
+		return args.Ed.GoDebug.SelectGoroutine(nil)
+	}
+	u, err := strconv.ParseUint(a[1], 10, 64)
+	if err != nil {
+		return err
+	}
+	id := debug.GoroutineId(u)
+	return args.Ed.GoDebug.SelectGoroutine(&id)
+}
+
+func GoDebugTests(args *core.InternalCmdArgs) error {
+	return args.Ed.GoDebug.Tests()
+}
+
+func GoDebugTest(args *core.InternalCmdArgs) error {
+	a := args.Part.ArgsUnquoted()
+	if len(a) != 2 {
+		return fmt.Errorf("expecting test name or \"all\"")
+	}
+	if a[1] == "all" {
+		return args.Ed.GoDebug.SelectTest("")
+	}
+	return args.Ed.GoDebug.SelectTest(a[1])
+}
+
+func GoDebugWatch(args *core.InternalCmdArgs) error {
+	a := args.Part.ArgsUnquoted()
+	if len(a) != 2 {
+		return fmt.Errorf("expecting variable or selector to watch")
+	}
+	ed := args.Ed
+	expr := a[1]
+
+	erow, _ := core.ExistingERowOrNewBasic(ed, "+GoDebugWatch")
+	erow.Row.TextArea.SetBytesClearPos(nil)
+	erow.Flash()
+
+	erow.Exec.RunAsync(func(ctx context.Context, rw io.ReadWriter) error {
+		// NOTE: not running in UI goroutine here
+
+		s, err := ed.GoDebug.Watch(expr)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(rw, s)
+		return nil
+	})
+	return nil
+}
+
 //----------
 
 func ColorTheme(args *core.InternalCmdArgs) error {
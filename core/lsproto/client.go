@@ -6,33 +6,49 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/jmigpin/editor/util/iout"
-	"github.com/jmigpin/editor/util/iout/iorw"
 
 	"golang.org/x/exp/jsonrpc2"
 )
 
 type Client struct {
 	conn *jsonrpc2.Connection
+	tap  *clientTap
 
 	li *LangInstance
 
 	lock struct {
 		sync.Mutex
 		fversions map[string]int
-		//folders   []*WorkspaceFolder
+		docs      map[string]*clientDoc // open documents
+		folders   []*WorkspaceFolder
 	}
+	docSend sync.Mutex // keeps the order of the document notifications (not used by UI paths)
 
-	serverCapabilities struct {
+	serverCapabilities struct { // written at initialize with the lock
 		workspace struct {
-			folders bool
-			symbol  bool
+			folders       bool
+			foldersChange bool // accepts "workspace/didChangeWorkspaceFolders"
+			symbol        bool
+		}
+		rename           bool
+		textDocumentSync TextDocumentSyncKind
+		save             struct { // textDocument/didSave
+			on          bool
+			includeText bool
+		}
+		codeActionResolve bool
+		inlayHint         bool
+		semanticTokens    struct {
+			full   bool
+			delta  bool
+			legend SemanticTokensLegend
 		}
-		rename bool
 	}
 }
 
@@ -52,9 +68,15 @@ func NewClientTCP(ctx context.Context, addr string, li *LangInstance) (*Client,
 func NewClientIO(ctx context.Context, rwc io.ReadWriteCloser, li *LangInstance) (*Client, error) {
 	cli := &Client{li: li}
 	cli.lock.fversions = map[string]int{}
+	cli.lock.docs = map[string]*clientDoc{}
+
+	// tap messages (traces, pending requests)
+	cli.tap = newClientTap(rwc, li)
+	rwc = cli.tap
 
 	rwcd := &RwcDialer{rwc: rwc}
 	opts := jsonrpc2.ConnectionOptions{}
+	opts.Handler = jsonrpc2.HandlerFunc(cli.handle)
 	conn, err := jsonrpc2.Dial(ctx, rwcd, opts)
 	if err != nil {
 		rwc.Close()
@@ -117,6 +139,73 @@ func (cli *Client) CallNoReply(ctx context.Context, method string, args, reply a
 
 //----------
 
+// Handles server requests/notifications.
+func (cli *Client) handle(ctx context.Context, req *jsonrpc2.Request) (any, error) {
+	switch req.Method {
+	case "textDocument/publishDiagnostics":
+		opt := &PublishDiagnosticsParams{}
+		if err := decodeJsonRaw(req.Params, opt); err != nil {
+			return nil, err
+		}
+		return nil, cli.li.lang.man.setDiagnostics(cli.li.lang, opt)
+	case "window/showMessage", "window/logMessage", "window/showMessageRequest":
+		opt := &ShowMessageParams{}
+		if err := decodeJsonRaw(req.Params, opt); err != nil {
+			return nil, err
+		}
+		// log level messages are too verbose
+		if !(req.Method == "window/logMessage" && opt.Type >= MessageTypeLog) {
+			msg := fmt.Sprintf("%v: %v", opt.Type, opt.Message)
+			cli.li.lang.man.Message(cli.li.lang.WrapMsg(msg))
+		}
+		if req.IsCall() {
+			return json.RawMessage("null"), nil // no action chosen
+		}
+		return nil, nil
+	case "workspace/configuration":
+		opt := &ConfigurationParams{}
+		if err := decodeJsonRaw(req.Params, opt); err != nil {
+			return nil, err
+		}
+		return cli.configuration(opt.Items)
+	case "client/registerCapability", "client/unregisterCapability":
+		return json.RawMessage("null"), nil
+	case "workspace/workspaceFolders":
+		cli.lock.Lock()
+		defer cli.lock.Unlock()
+		if len(cli.lock.folders) == 0 {
+			return json.RawMessage("null"), nil // no workspace open
+		}
+		return cli.lock.folders, nil
+	case "workspace/applyEdit":
+		opt := &ApplyWorkspaceEditParams{}
+		if err := decodeJsonRaw(req.Params, opt); err != nil {
+			return nil, err
+		}
+		res := &ApplyWorkspaceEditResult{Applied: true}
+		if err := cli.li.lang.man.applyWorkspaceEdit(ctx, &opt.Edit); err != nil {
+			res = &ApplyWorkspaceEditResult{FailureReason: err.Error()}
+		}
+		return res, nil
+	case "window/workDoneProgress/create":
+		opt := &WorkDoneProgressCreateParams{}
+		if err := decodeJsonRaw(req.Params, opt); err != nil {
+			return nil, err
+		}
+		cli.li.lang.man.createProgress(cli, opt.Token)
+		return json.RawMessage("null"), nil
+	case "$/progress":
+		opt := &ProgressParams{}
+		if err := decodeJsonRaw(req.Params, opt); err != nil {
+			return nil, err
+		}
+		return nil, cli.li.lang.man.updateProgress(opt)
+	}
+	return nil, jsonrpc2.ErrNotHandled
+}
+
+//----------
+
 //func (cli *Client) onNotificationMessage(msg *NotificationMessage) {
 //	// Msgs like:
 //	// - a notification was sent to the srv, not expecting a reply, but it receives one because it was an error (has id)
@@ -175,65 +264,145 @@ func (cli *Client) Initialize(ctx context.Context) error {
 	}
 	logJson("initialize <--: ", serverCapabilities)
 
-	cli.readServerCapabilities(serverCapabilities)
+	cli.lock.Lock()
+	cli.readServerCapabilities_noLock(serverCapabilities)
+	cli.lock.Unlock()
 
 	// send "initialized" (gopls: "no views" error without this)
 	opt2 := json.RawMessage("{}")
-	return cli.CallNoReply(ctx, "initialized", opt2, nil)
+	if err := cli.CallNoReply(ctx, "initialized", opt2, nil); err != nil {
+		return err
+	}
+
+	// servers that don't request the configuration get it here
+	if cfg, ok := cli.config(); ok {
+		opt3 := &DidChangeConfigurationParams{Settings: cfg}
+		return cli.CallNoReply(ctx, "workspace/didChangeConfiguration", opt3, nil)
+	}
+	return nil
 }
 
 func (cli *Client) initializeParams() (json.RawMessage, error) {
-	opt := []string{"\"capabilities\":{}"}
-
-	//	rootUri, err := cli.rootUri()
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//	_ = rootUri
-
-	//	// workspace folders
-	//	cli.folders = []*WorkspaceFolder{{Uri: rootUri}}
-	//	foldersBytes, err := encodeJson(cli.folders)
-	//	if err != nil {
-	//		return nil, err
-	//	}
-
-	//	// other capabilities
-	//	//"capabilities":{
-	//	//	"workspace":{
-	//	//		"configuration":true,
-	//	//		"workspaceFolders":true
-	//	//	},
-	//	//	"textDocument":{
-	//	//		"publishDiagnostics":{
-	//	//			"relatedInformation":true
-	//	//		}
-	//	//	}
-	//	//}
-
-	//	raw := json.RawMessage("{" +
-	//		// TODO: gopls is not allowing rooturi=null at the moment...
-	//		fmt.Sprintf("%q:%q", "rootUri", rootUri) + "," +
-	//		// set workspace folders to use the later as "remove" value
-	//		fmt.Sprintf("%q:%s", "workspaceFolders", foldersBytes) +
-	//		"}")
+	caps, err := json.Marshal(cli.clientCapabilities())
+	if err != nil {
+		return nil, err
+	}
+	opt := []string{fmt.Sprintf("%q:%s", "capabilities", caps)}
+
+	// workspace folders
+	if root := cli.li.root; root != "" {
+		folder, err := newWorkspaceFolder(root)
+		if err != nil {
+			return nil, err
+		}
+		folders := []*WorkspaceFolder{folder}
+		cli.lock.Lock()
+		cli.lock.folders = folders
+		cli.lock.Unlock()
+		foldersBytes, err := json.Marshal(folders)
+		if err != nil {
+			return nil, err
+		}
+		opt = append(opt,
+			fmt.Sprintf("%q:%q", "rootUri", folder.Uri),
+			fmt.Sprintf("%q:%s", "workspaceFolders", foldersBytes),
+		)
+	}
 
 	raw := "{" + strings.Join(opt, ",") + "}"
 	return json.RawMessage(raw), nil
 }
 
-//func (cli *Client) rootUri() (DocumentUri, error) {
-//	// using a non-existent dir to prevent an lsp server to start scanning the user disk doesn't work well (ex: gopls gives "no views in the session" after the cache is gone)
-//	// use initial request file
-//	dir := filepath.Dir(cli.li.lang.InstanceReqFilename)
-//	rootUrl, err := absFilenameToUrl(dir)
-//	if err != nil {
-//		return "", err
-//	}
-//	return DocumentUri(rootUrl), nil
-//}
+func (cli *Client) clientCapabilities() map[string]any {
+	return map[string]any{
+		"window": map[string]any{
+			"workDoneProgress": true,
+		},
+		"workspace": map[string]any{
+			"applyEdit":        true,
+			"configuration":    true,
+			"workspaceFolders": true,
+		},
+		"textDocument": map[string]any{
+			"publishDiagnostics": map[string]any{
+				"relatedInformation": false,
+			},
+			"hover": map[string]any{
+				"contentFormat": []string{"plaintext", "markdown"},
+			},
+			"signatureHelp": map[string]any{
+				"signatureInformation": map[string]any{
+					"documentationFormat": []string{"plaintext", "markdown"},
+					"parameterInformation": map[string]any{
+						"labelOffsetSupport": true,
+					},
+					"activeParameterSupport": true,
+				},
+			},
+			"inlayHint": map[string]any{},
+			"semanticTokens": map[string]any{
+				"requests": map[string]any{
+					"full": map[string]any{
+						"delta": true,
+					},
+				},
+				"tokenTypes":              semanticTokenTypes,
+				"tokenModifiers":          semanticTokenModifiers,
+				"formats":                 []string{"relative"},
+				"overlappingTokenSupport": false,
+				"multilineTokenSupport":   false,
+			},
+			"documentSymbol": map[string]any{
+				"hierarchicalDocumentSymbolSupport": true,
+			},
+			"codeAction": map[string]any{
+				"codeActionLiteralSupport": map[string]any{
+					"codeActionKind": map[string]any{
+						"valueSet": []string{"", "quickfix", "refactor", "refactor.extract", "refactor.inline", "refactor.rewrite", "source", "source.organizeImports", "source.fixAll"},
+					},
+				},
+				"isPreferredSupport": true,
+				"dataSupport":        true,
+				"resolveSupport": map[string]any{
+					"properties": []string{"edit"},
+				},
+			},
+		},
+	}
+}
+
+// Registration config json.
+func (cli *Client) config() (any, bool) {
+	s := cli.li.lang.Reg.Config
+	if s == "" {
+		return nil, false
+	}
+	v := (any)(nil)
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// Answers each item with the respective section of the registration config (null if not found).
+func (cli *Client) configuration(items []*ConfigurationItem) ([]any, error) {
+	cfg, ok := cli.config()
+	res := []any{}
+	for _, item := range items {
+		v := (any)(nil)
+		if ok {
+			if item.Section == "" {
+				v = cfg
+			} else if v2, err := JsonGetPath(cfg, item.Section); err == nil {
+				v = v2
+			}
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
 
-func (cli *Client) readServerCapabilities(caps any) {
+func (cli *Client) readServerCapabilities_noLock(caps any) {
 	path := "capabilities.workspace.workspaceFolders.supported"
 	v, err := JsonGetPath(caps, path)
 	if err == nil {
@@ -242,12 +411,98 @@ func (cli *Client) readServerCapabilities(caps any) {
 		}
 	}
 
+	// can be a bool or a registration id string
+	path = "capabilities.workspace.workspaceFolders.changeNotifications"
+	v, err = JsonGetPath(caps, path)
+	if err == nil {
+		switch t := v.(type) {
+		case bool:
+			cli.serverCapabilities.workspace.foldersChange = t
+		case string:
+			cli.serverCapabilities.workspace.foldersChange = t != ""
+		}
+	}
+
 	path = "capabilities.workspaceSymbolProvider"
 	v, err = JsonGetPath(caps, path)
 	if err == nil {
 		if b, ok := v.(bool); ok && b == true {
 			cli.serverCapabilities.workspace.symbol = true
 		}
+		if _, ok := v.(map[string]any); ok { // options
+			cli.serverCapabilities.workspace.symbol = true
+		}
+	}
+
+	// can be a number or an object
+	for _, path := range []string{
+		"capabilities.textDocumentSync.change",
+		"capabilities.textDocumentSync",
+	} {
+		v, err := JsonGetPath(caps, path)
+		if err == nil {
+			if f, ok := v.(float64); ok {
+				cli.serverCapabilities.textDocumentSync = TextDocumentSyncKind(f)
+				break
+			}
+		}
+	}
+
+	// can be a bool or an object with options
+	path = "capabilities.textDocumentSync.save"
+	v, err = JsonGetPath(caps, path)
+	if err == nil {
+		if b, ok := v.(bool); ok && b == true {
+			cli.serverCapabilities.save.on = true
+		}
+		if m, ok := v.(map[string]any); ok {
+			cli.serverCapabilities.save.on = true
+			if b, ok := m["includeText"].(bool); ok {
+				cli.serverCapabilities.save.includeText = b
+			}
+		}
+	}
+
+	path = "capabilities.codeActionProvider.resolveProvider"
+	v, err = JsonGetPath(caps, path)
+	if err == nil {
+		if b, ok := v.(bool); ok && b == true {
+			cli.serverCapabilities.codeActionResolve = true
+		}
+	}
+
+	// can be a bool or an object with options
+	path = "capabilities.inlayHintProvider"
+	v, err = JsonGetPath(caps, path)
+	if err == nil {
+		if b, ok := v.(bool); ok && b == true {
+			cli.serverCapabilities.inlayHint = true
+		}
+		if _, ok := v.(map[string]any); ok {
+			cli.serverCapabilities.inlayHint = true
+		}
+	}
+
+	// full can be a bool or an object with delta
+	path = "capabilities.semanticTokensProvider.full"
+	v, err = JsonGetPath(caps, path)
+	if err == nil {
+		if b, ok := v.(bool); ok && b == true {
+			cli.serverCapabilities.semanticTokens.full = true
+		}
+		if m, ok := v.(map[string]any); ok {
+			cli.serverCapabilities.semanticTokens.full = true
+			if b, ok := m["delta"].(bool); ok && b == true {
+				cli.serverCapabilities.semanticTokens.delta = true
+			}
+		}
+	}
+	path = "capabilities.semanticTokensProvider.legend"
+	v, err = JsonGetPath(caps, path)
+	if err == nil {
+		if b, err := json.Marshal(v); err == nil {
+			_ = json.Unmarshal(b, &cli.serverCapabilities.semanticTokens.legend)
+		}
 	}
 
 	path = "capabilities.renameProvider"
@@ -313,7 +568,7 @@ func (cli *Client) TextDocumentDidClose(ctx context.Context, filename string) er
 	return cli.CallNoReply(ctx, "textDocument/didClose", opt, nil)
 }
 
-func (cli *Client) TextDocumentDidChange(ctx context.Context, filename, text string, version int) error {
+func (cli *Client) TextDocumentDidChange(ctx context.Context, filename string, changes []*TextDocumentContentChangeEvent, version int) error {
 	// https://microsoft.github.io/language-server-protocol/specification#textDocument_didChange
 
 	opt := &DidChangeTextDocumentParams{}
@@ -323,25 +578,7 @@ func (cli *Client) TextDocumentDidChange(ctx context.Context, filename, text str
 		return err
 	}
 	opt.TextDocument.Uri = DocumentUri(url)
-
-	// text end line/column
-	rd := iorw.NewStringReaderAt(text)
-	pos, err := OffsetToPosition(rd, len(text))
-	if err != nil {
-		return err
-	}
-
-	// changes
-	opt.ContentChanges = []*TextDocumentContentChangeEvent{
-		{
-			Range: Range{
-				Start: Position{0, 0},
-				End:   pos,
-			},
-			//RangeLength: len(text), // TODO: not working?
-			Text: text,
-		},
-	}
+	opt.ContentChanges = changes
 	return cli.CallNoReply(ctx, "textDocument/didChange", opt, nil)
 }
 
@@ -401,6 +638,44 @@ func (cli *Client) TextDocumentImplementation(ctx context.Context, filename stri
 
 //----------
 
+func (cli *Client) TextDocumentTypeDefinition(ctx context.Context, filename string, pos Position) ([]*Location, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_typeDefinition
+
+	opt := &TextDocumentPositionParams{}
+	opt.Position = pos
+	url, err := AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+
+	result := locationResponseUnion{}
+	if err := cli.Call(ctx, "textDocument/typeDefinition", opt, &result); err != nil {
+		return nil, err
+	}
+	return result.locs, nil
+}
+
+func (cli *Client) TextDocumentDeclaration(ctx context.Context, filename string, pos Position) ([]*Location, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_declaration
+
+	opt := &TextDocumentPositionParams{}
+	opt.Position = pos
+	url, err := AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+
+	result := locationResponseUnion{}
+	if err := cli.Call(ctx, "textDocument/declaration", opt, &result); err != nil {
+		return nil, err
+	}
+	return result.locs, nil
+}
+
+//----------
+
 func (cli *Client) TextDocumentCompletion(ctx context.Context, filename string, pos Position) (*CompletionList, error) {
 	// https://microsoft.github.io/language-server-protocol/specification#textDocument_completion
 
@@ -424,48 +699,69 @@ func (cli *Client) TextDocumentCompletion(ctx context.Context, filename string,
 
 //----------
 
-func (cli *Client) TextDocumentDidOpenVersion(ctx context.Context, filename string, b []byte) error {
+func (cli *Client) TextDocumentHover(ctx context.Context, filename string, pos Position) (*Hover, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_hover
 
-	cli.lock.Lock()
-	v, ok := cli.lock.fversions[filename]
-	if !ok {
-		v = 1
-	} else {
-		v++
+	opt := &TextDocumentPositionParams{}
+	opt.Position = pos
+	url, err := AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
 	}
-	cli.lock.fversions[filename] = v
-	cli.lock.Unlock()
+	opt.TextDocument.Uri = DocumentUri(url)
 
-	return cli.TextDocumentDidOpen(ctx, filename, string(b), v)
+	result := (*Hover)(nil) // can be null
+	if err := cli.Call(ctx, "textDocument/hover", opt, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 //----------
 
-//func (cli *Client) WorkspaceDidChangeWorkspaceFolders(ctx context.Context, added, removed []*WorkspaceFolder) error {
-//	opt := &DidChangeWorkspaceFoldersParams{}
-//	opt.Event = &WorkspaceFoldersChangeEvent{}
-//	opt.Event.Added = added
-//	opt.Event.Removed = removed
-//	err := cli.CallNoReply(ctx, "workspace/didChangeWorkspaceFolders", opt, nil)
-//	return err
-//}
+func (cli *Client) WorkspaceDidChangeWorkspaceFolders(ctx context.Context, added, removed []*WorkspaceFolder) error {
+	// https://microsoft.github.io/language-server-protocol/specification#workspace_didChangeWorkspaceFolders
 
-//----------
+	opt := &DidChangeWorkspaceFoldersParams{}
+	opt.Event = &WorkspaceFoldersChangeEvent{}
+	opt.Event.Added = added
+	opt.Event.Removed = removed
+	return cli.CallNoReply(ctx, "workspace/didChangeWorkspaceFolders", opt, nil)
+}
 
-//func (cli *Client) UpdateWorkspaceFolder(ctx context.Context, dir string) error {
-//	if !cli.serverCapabilities.workspace.folders {
-//		return nil
-//	}
+// Adds the dir to the workspace folders if not present. Does nothing if the server doesn't accept folder changes.
+func (cli *Client) addWorkspaceFolder(ctx context.Context, dir string) error {
+	if !cli.serverCapabilities.workspace.foldersChange {
+		return nil
+	}
+	folder, err := newWorkspaceFolder(dir)
+	if err != nil {
+		return err
+	}
 
-//	removed := cli.folders
-//	url, err := absFilenameToUrl(dir)
-//	if err != nil {
-//		return err
-//	}
-//	cli.folders = []*WorkspaceFolder{{Uri: DocumentUri(url)}}
-//	return cli.WorkspaceDidChangeWorkspaceFolders(ctx, cli.folders, removed)
+	cli.lock.Lock()
+	for _, f := range cli.lock.folders {
+		if f.Uri == folder.Uri {
+			cli.lock.Unlock()
+			return nil
+		}
+	}
+	cli.lock.folders = append(cli.lock.folders, folder)
+	cli.lock.Unlock()
 
-//}
+	added := []*WorkspaceFolder{folder}
+	return cli.WorkspaceDidChangeWorkspaceFolders(ctx, added, []*WorkspaceFolder{})
+}
+
+func newWorkspaceFolder(dir string) (*WorkspaceFolder, error) {
+	url, err := AbsFilenameToUrl(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &WorkspaceFolder{Uri: DocumentUri(url), Name: filepath.Base(dir)}, nil
+}
+
+//----------
 
 // TODO
 //return cli.WorkspaceDidChangeConfiguration(ctx, dir)
@@ -516,10 +812,11 @@ func (cli *Client) TextDocumentPrepareCallHierarchy(ctx context.Context, filenam
 	err = cli.Call(ctx, "textDocument/prepareCallHierarchy", opt, &result)
 	return result, err
 }
-func (cli *Client) CallHierarchyCalls(ctx context.Context, typ CallHierarchyCallType, item *CallHierarchyItem) ([]*CallHierarchyCall, error) {
+func (cli *Client) CallHierarchyCalls(ctx context.Context, typ CallHierarchyCallType, item *CallHierarchyItem, workDoneToken ProgressToken) ([]*CallHierarchyCall, error) {
 	switch typ {
 	case IncomingChct:
 		opt := &CallHierarchyIncomingCallsParams{Item: item}
+		opt.WorkDoneToken = workDoneToken
 		result := []*CallHierarchyIncomingCall{}
 		if err := cli.Call(ctx, "callHierarchy/incomingCalls", opt, &result); err != nil {
 			return nil, err
@@ -531,6 +828,7 @@ func (cli *Client) CallHierarchyCalls(ctx context.Context, typ CallHierarchyCall
 		return res, nil
 	case OutgoingChct:
 		opt := &CallHierarchyOutgoingCallsParams{Item: item}
+		opt.WorkDoneToken = workDoneToken
 		result := []*CallHierarchyOutgoingCall{}
 		if err := cli.Call(ctx, "callHierarchy/outgoingCalls", opt, &result); err != nil {
 			return nil, err
@@ -547,9 +845,44 @@ func (cli *Client) CallHierarchyCalls(ctx context.Context, typ CallHierarchyCall
 
 //----------
 
-func (cli *Client) TextDocumentReferences(ctx context.Context, filename string, pos Position) ([]*Location, error) {
+func (cli *Client) TextDocumentPrepareTypeHierarchy(ctx context.Context, filename string, pos Position) ([]*TypeHierarchyItem, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_prepareTypeHierarchy
+
+	opt := &TypeHierarchyPrepareParams{}
+	opt.Position = pos
+	url, err := AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+	result := []*TypeHierarchyItem{}
+	err = cli.Call(ctx, "textDocument/prepareTypeHierarchy", opt, &result)
+	return result, err
+}
+func (cli *Client) TypeHierarchyTypes(ctx context.Context, typ TypeHierarchyType, item *TypeHierarchyItem, workDoneToken ProgressToken) ([]*TypeHierarchyItem, error) {
+	result := []*TypeHierarchyItem{}
+	switch typ {
+	case SupertypesTht:
+		opt := &TypeHierarchySupertypesParams{Item: item}
+		opt.WorkDoneToken = workDoneToken
+		err := cli.Call(ctx, "typeHierarchy/supertypes", opt, &result)
+		return result, err
+	case SubtypesTht:
+		opt := &TypeHierarchySubtypesParams{Item: item}
+		opt.WorkDoneToken = workDoneToken
+		err := cli.Call(ctx, "typeHierarchy/subtypes", opt, &result)
+		return result, err
+	default:
+		panic("bad type")
+	}
+}
+
+//----------
+
+func (cli *Client) TextDocumentReferences(ctx context.Context, filename string, pos Position, workDoneToken ProgressToken) ([]*Location, error) {
 	opt := &ReferenceParams{}
 	opt.Context.IncludeDeclaration = true
+	opt.WorkDoneToken = workDoneToken
 	url, err := AbsFilenameToUrl(filename)
 	if err != nil {
 		return nil, err
@@ -561,6 +894,212 @@ func (cli *Client) TextDocumentReferences(ctx context.Context, filename string,
 	return result, err
 }
 
+//----------
+
+func (cli *Client) TextDocumentFormatting(ctx context.Context, filename string, opts FormattingOptions) ([]*TextEdit, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_formatting
+
+	opt := &DocumentFormattingParams{}
+	opt.Options = opts
+	url, err := AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+	result := []*TextEdit{}
+	err = cli.Call(ctx, "textDocument/formatting", opt, &result)
+	return result, err
+}
+
+func (cli *Client) TextDocumentRangeFormatting(ctx context.Context, filename string, rang Range, opts FormattingOptions) ([]*TextEdit, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_rangeFormatting
+
+	opt := &DocumentRangeFormattingParams{}
+	opt.Range = rang
+	opt.Options = opts
+	url, err := AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+	result := []*TextEdit{}
+	err = cli.Call(ctx, "textDocument/rangeFormatting", opt, &result)
+	return result, err
+}
+
+//----------
+
+func (cli *Client) TextDocumentSignatureHelp(ctx context.Context, filename string, pos Position, triggerChar string) (*SignatureHelp, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_signatureHelp
+
+	opt := &SignatureHelpParams{}
+	opt.Position = pos
+	opt.Context = &SignatureHelpContext{TriggerKind: 1}
+	if triggerChar != "" {
+		opt.Context.TriggerKind = 2
+		opt.Context.TriggerCharacter = triggerChar
+	}
+	url, err := AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+
+	result := (*SignatureHelp)(nil) // can be null
+	if err := cli.Call(ctx, "textDocument/signatureHelp", opt, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+//----------
+
+func (cli *Client) TextDocumentDocumentSymbol(ctx context.Context, filename string) ([]*DocumentSymbol, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_documentSymbol
+
+	opt := &DocumentSymbolParams{}
+	url, err := AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+	result := documentSymbolResponseUnion{}
+	if err := cli.Call(ctx, "textDocument/documentSymbol", opt, &result); err != nil {
+		return nil, err
+	}
+	return result.syms, nil
+}
+
+func (cli *Client) WorkspaceSymbol(ctx context.Context, query string, workDoneToken ProgressToken) ([]*SymbolInformation, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#workspace_symbol
+
+	if !cli.serverCapabilities.workspace.symbol {
+		return nil, fmt.Errorf("server does not support workspace symbols")
+	}
+
+	opt := &WorkspaceSymbolParams{Query: query}
+	opt.WorkDoneToken = workDoneToken
+	result := []*SymbolInformation{}
+	err := cli.Call(ctx, "workspace/symbol", opt, &result)
+	return result, err
+}
+
+//----------
+
+func (cli *Client) TextDocumentInlayHint(ctx context.Context, filename string, rang Range) ([]*InlayHint, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_inlayHint
+
+	if !cli.serverCapabilities.inlayHint {
+		return nil, fmt.Errorf("server does not support inlay hints")
+	}
+
+	opt := &InlayHintParams{Range: rang}
+	url, err := AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+	result := []*InlayHint{} // can be null
+	if err := cli.Call(ctx, "textDocument/inlayHint", opt, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+//----------
+
+func (cli *Client) TextDocumentSemanticTokensFull(ctx context.Context, filename string) (*SemanticTokens, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_semanticTokens
+
+	if !cli.serverCapabilities.semanticTokens.full {
+		return nil, fmt.Errorf("server does not support semantic tokens")
+	}
+
+	opt := &SemanticTokensParams{}
+	url, err := AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+	result := (*SemanticTokens)(nil) // can be null
+	if err := cli.Call(ctx, "textDocument/semanticTokens/full", opt, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (cli *Client) TextDocumentSemanticTokensFullDelta(ctx context.Context, filename string, previousResultId string) (*SemanticTokensDelta, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_semanticTokens
+
+	if !cli.serverCapabilities.semanticTokens.delta {
+		return nil, fmt.Errorf("server does not support semantic tokens delta")
+	}
+
+	opt := &SemanticTokensDeltaParams{PreviousResultId: previousResultId}
+	url, err := AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+	result := (*SemanticTokensDelta)(nil) // can be null
+	if err := cli.Call(ctx, "textDocument/semanticTokens/full/delta", opt, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+//----------
+
+func (cli *Client) TextDocumentCodeAction(ctx context.Context, filename string, rang Range, diags []*Diagnostic) ([]*CodeAction, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_codeAction
+
+	opt := &CodeActionParams{}
+	opt.Range = rang
+	opt.Context.Diagnostics = diags
+	if opt.Context.Diagnostics == nil {
+		opt.Context.Diagnostics = []*Diagnostic{} // not null
+	}
+	url, err := AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+	result := codeActionResponseUnion{}
+	if err := cli.Call(ctx, "textDocument/codeAction", opt, &result); err != nil {
+		return nil, err
+	}
+	return result.actions, nil
+}
+
+func (cli *Client) CodeActionResolve(ctx context.Context, ca *CodeAction) (*CodeAction, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#codeAction_resolve
+
+	result := &CodeAction{}
+	if err := cli.Call(ctx, "codeAction/resolve", ca, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (cli *Client) WorkspaceExecuteCommand(ctx context.Context, cmd *Command) error {
+	// https://microsoft.github.io/language-server-protocol/specification#workspace_executeCommand
+
+	opt := &ExecuteCommandParams{}
+	opt.Command = cmd.Command
+	opt.Arguments = cmd.Arguments
+	result := json.RawMessage{} // result is ignored
+	return cli.Call(ctx, "workspace/executeCommand", opt, &result)
+}
+
+//----------
+
+func (cli *Client) WindowWorkDoneProgressCancel(ctx context.Context, token ProgressToken) error {
+	// https://microsoft.github.io/language-server-protocol/specification#window_workDoneProgress_cancel
+
+	opt := &WorkDoneProgressCancelParams{Token: token}
+	return cli.CallNoReply(ctx, "window/workDoneProgress/cancel", opt, nil)
+}
+
 //----------
 //----------
 //----------
@@ -0,0 +1,285 @@
+package lsproto
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf16"
+
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// Documents are kept open in the server after the first request. Writes are sent as incremental changes (textDocument/didChange), and the full content is only sent if the server doesn't support incremental changes, or if the known content diverged from the content given in a request.
+
+// delay before sending pending writes to the server
+var docChangesFlushDelay = 300 * time.Millisecond
+
+type clientDoc struct {
+	version int
+	text    []byte      // content as known by the server
+	writes  []*docWrite // pending writes, not yet sent to the server
+	timer   *time.Timer // flush timer
+
+	semTokens *SemanticTokens // last result, used in delta requests
+}
+
+type docWrite struct {
+	index int
+	n     int // n deleted bytes
+	p     []byte
+}
+
+//----------
+
+// Ensures the server has the content of rd.
+func (cli *Client) syncDocument(ctx context.Context, filename string, rd iorw.ReaderAt) error {
+	b, err := iorw.ReadFullCopy(rd)
+	if err != nil {
+		return err
+	}
+
+	cli.docSend.Lock()
+	defer cli.docSend.Unlock()
+
+	sends, err := cli.syncDocumentSends(filename, b)
+	for _, ds := range sends {
+		if err2 := cli.sendDocument(ctx, filename, ds); err2 != nil {
+			return err2
+		}
+	}
+	return err
+}
+
+// Ensures the server has the content of rd, and notifies the save if the server wants it.
+func (cli *Client) saveDocument(ctx context.Context, filename string, rd iorw.ReaderAt) error {
+	if err := cli.syncDocument(ctx, filename, rd); err != nil {
+		return err
+	}
+	sc := &cli.serverCapabilities
+	if !sc.save.on {
+		return nil
+	}
+	text := []byte(nil)
+	if sc.save.includeText {
+		b, err := iorw.ReadFastFull(rd)
+		if err != nil {
+			return err
+		}
+		text = b
+	}
+	return cli.TextDocumentDidSave(ctx, filename, text)
+}
+
+func (cli *Client) syncDocumentSends(filename string, b []byte) ([]*docSend, error) {
+	cli.lock.Lock()
+	defer cli.lock.Unlock()
+
+	doc, ok := cli.lock.docs[filename]
+	if !ok {
+		v := cli.nextVersion_noLock(filename)
+		doc = &clientDoc{version: v, text: b}
+		cli.lock.docs[filename] = doc
+		return []*docSend{{doc: doc, open: true, text: b, version: v}}, nil
+	}
+
+	sends := []*docSend{}
+	ds, err := cli.flushDocument_noLock(filename, doc)
+	if ds != nil {
+		sends = append(sends, ds)
+	}
+	if err != nil {
+		return sends, err
+	}
+
+	// content diverged (ex: writes not reported), send full content
+	if !bytes.Equal(doc.text, b) {
+		changes := []*TextDocumentContentChangeEvent{{Text: string(b)}}
+		sends = append(sends, cli.documentChanges_noLock(filename, doc, changes))
+		doc.text = b
+	}
+	return sends, nil
+}
+
+// Returns true if the document is open in the server.
+func (cli *Client) hasDocument(filename string) bool {
+	cli.lock.Lock()
+	defer cli.lock.Unlock()
+	_, ok := cli.lock.docs[filename]
+	return ok
+}
+
+// Adds a write to the pending changes of an open document. The changes are sent after a short delay, or on the next request for the document.
+func (cli *Client) documentWrite(filename string, index, n int, p []byte) {
+	cli.lock.Lock()
+	defer cli.lock.Unlock()
+
+	doc, ok := cli.lock.docs[filename]
+	if !ok {
+		return
+	}
+	doc.writes = append(doc.writes, &docWrite{index, n, p})
+
+	if doc.timer == nil {
+		doc.timer = time.AfterFunc(docChangesFlushDelay, func() {
+			cli.docSend.Lock()
+			defer cli.docSend.Unlock()
+
+			cli.lock.Lock()
+			if cli.lock.docs[filename] != doc {
+				cli.lock.Unlock()
+				return // document was closed
+			}
+			ds, err := cli.flushDocument_noLock(filename, doc)
+			cli.lock.Unlock()
+
+			ctx := cli.li.ctx
+			if ds != nil {
+				if err2 := cli.sendDocument(ctx, filename, ds); err == nil {
+					err = err2
+				}
+			}
+			if err != nil && ctx.Err() == nil {
+				cli.li.lang.PrintWrapError(err)
+			}
+		})
+	}
+}
+
+func (cli *Client) closeDocument(ctx context.Context, filename string) error {
+	cli.docSend.Lock()
+	defer cli.docSend.Unlock()
+
+	cli.lock.Lock()
+	doc, ok := cli.lock.docs[filename]
+	if ok {
+		cli.stopFlushTimer_noLock(doc)
+		delete(cli.lock.docs, filename)
+	}
+	cli.lock.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return cli.sendDocument(ctx, filename, &docSend{close: true})
+}
+
+//----------
+
+// Document notification to be sent after releasing the lock (sends can block on a slow server, and the lock is used by the UI writes). Sends are kept in order by the docSend lock.
+type docSend struct {
+	doc     *clientDoc
+	open    bool
+	close   bool
+	text    []byte // open
+	changes []*TextDocumentContentChangeEvent
+	version int
+}
+
+func (cli *Client) sendDocument(ctx context.Context, filename string, ds *docSend) error {
+	var err error
+	switch {
+	case ds.close:
+		return cli.TextDocumentDidClose(ctx, filename)
+	case ds.open:
+		err = cli.TextDocumentDidOpen(ctx, filename, string(ds.text), ds.version)
+	default:
+		err = cli.TextDocumentDidChange(ctx, filename, ds.changes, ds.version)
+	}
+	if err != nil {
+		// content known by the server is uncertain: forget the document, the next request will open it with the full content
+		cli.lock.Lock()
+		if cli.lock.docs[filename] == ds.doc {
+			cli.stopFlushTimer_noLock(ds.doc)
+			delete(cli.lock.docs, filename)
+		}
+		cli.lock.Unlock()
+	}
+	return err
+}
+
+//----------
+
+// Takes the pending writes, and returns the notification to send (nil if there are no writes). The document state is updated as if the notification was sent.
+func (cli *Client) flushDocument_noLock(filename string, doc *clientDoc) (*docSend, error) {
+	cli.stopFlushTimer_noLock(doc)
+	if len(doc.writes) == 0 {
+		return nil, nil
+	}
+	writes := doc.writes
+	doc.writes = nil
+
+	text, changes, err := docWritesChanges(doc.text, writes)
+	if err != nil {
+		// unable to apply the writes: close the document, the next request will open it with the full content
+		delete(cli.lock.docs, filename)
+		return &docSend{close: true}, err
+	}
+
+	if cli.serverCapabilities.textDocumentSync != TextDocumentSyncKindIncremental {
+		changes = []*TextDocumentContentChangeEvent{{Text: string(text)}}
+	}
+
+	ds := cli.documentChanges_noLock(filename, doc, changes)
+	doc.text = text
+	return ds, nil
+}
+
+func (cli *Client) documentChanges_noLock(filename string, doc *clientDoc, changes []*TextDocumentContentChangeEvent) *docSend {
+	v := cli.nextVersion_noLock(filename)
+	doc.version = v
+	return &docSend{doc: doc, changes: changes, version: v}
+}
+
+func (cli *Client) stopFlushTimer_noLock(doc *clientDoc) {
+	if doc.timer != nil {
+		doc.timer.Stop()
+		doc.timer = nil
+	}
+}
+
+func (cli *Client) nextVersion_noLock(filename string) int {
+	v, ok := cli.lock.fversions[filename]
+	if !ok {
+		v = 1
+	} else {
+		v++
+	}
+	cli.lock.fversions[filename] = v
+	return v
+}
+
+//----------
+
+// Applies the writes to the text, returning the resulting text and the respective change events (ranges are relative to the text at the time of each write).
+func docWritesChanges(text []byte, writes []*docWrite) ([]byte, []*TextDocumentContentChangeEvent, error) {
+	changes := []*TextDocumentContentChangeEvent{}
+	for _, w := range writes {
+		if w.index < 0 || w.n < 0 || w.index+w.n > len(text) {
+			return nil, nil, fmt.Errorf("bad write: index=%v, n=%v, len=%v", w.index, w.n, len(text))
+		}
+		start := bytesOffsetToPosition(text, w.index)
+		end := bytesOffsetToPosition(text, w.index+w.n)
+		ch := &TextDocumentContentChangeEvent{
+			Range: &Range{Start: start, End: end},
+			Text:  string(w.p),
+		}
+		changes = append(changes, ch)
+
+		// apply write
+		u := make([]byte, 0, len(text)-w.n+len(w.p))
+		u = append(u, text[:w.index]...)
+		u = append(u, w.p...)
+		u = append(u, text[w.index+w.n:]...)
+		text = u
+	}
+	return text, changes, nil
+}
+
+func bytesOffsetToPosition(b []byte, offset int) Position {
+	u := b[:offset]
+	line := bytes.Count(u, []byte("\n"))
+	lineStart := bytes.LastIndexByte(u, '\n') + 1
+	col := len(utf16.Encode([]rune(string(u[lineStart:]))))
+	return Position{Line: line, Character: col}
+}
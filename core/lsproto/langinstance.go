@@ -15,10 +15,11 @@ type LangInstance struct {
 	ctx  context.Context
 	cli  *Client
 	sw   *ServerWrap // might be nil: "tcpclient" option
+	root string      // project root dir, might be empty
 }
 
-func NewLangInstance(ctx context.Context, lang *LangManager) (*LangInstance, error) {
-	li := &LangInstance{lang: lang}
+func NewLangInstance(ctx context.Context, lang *LangManager, root string) (*LangInstance, error) {
+	li := &LangInstance{lang: lang, root: root}
 
 	li.ctx = withLangInstanceNamedCancel(ctx)
 	earlyErrClear := func() {
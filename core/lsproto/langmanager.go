@@ -3,6 +3,9 @@ package lsproto
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 )
 
@@ -11,22 +14,50 @@ type LangManager struct {
 	Reg *Registration // accessed from editor
 	li  struct {
 		sync.Mutex
-		li     *LangInstance
-		cancel context.CancelFunc
+		m     map[string]*langInstanceCancel // key is the instance root ("" if none)
+		roots map[string]string              // cache of detected roots, key is the dir
 	}
 }
 
+type langInstanceCancel struct {
+	li     *LangInstance
+	cancel context.CancelFunc
+}
+
 func NewLangManager(man *Manager, reg *Registration) *LangManager {
-	return &LangManager{Reg: reg, man: man}
+	lang := &LangManager{Reg: reg, man: man}
+	lang.li.m = map[string]*langInstanceCancel{}
+	lang.li.roots = map[string]string{}
+	return lang
 }
 
-func (lang *LangManager) instance(startCtx context.Context) (*LangInstance, error) {
+// Returns the instance for the filename project root, starting one if needed.
+func (lang *LangManager) instance(startCtx context.Context, filename string) (*LangInstance, error) {
+	li, root, err := lang.instance2(startCtx, filename)
+	if err != nil {
+		return nil, err
+	}
+	// shared instance: add the root as a workspace folder (network notify, done without the instances lock)
+	if root != li.root && root != "" {
+		// best effort, the server might not support adding folders
+		if err := li.cli.addWorkspaceFolder(startCtx, root); err != nil {
+			lang.PrintWrapError(err)
+		}
+	}
+	return li, nil
+}
+
+// Returns the instance and the filename project root (can differ from the instance root if the instance is shared), holding the instances lock.
+func (lang *LangManager) instance2(startCtx context.Context, filename string) (*LangInstance, string, error) {
 	lang.li.Lock()
 	defer lang.li.Unlock()
 
+	root := lang.root(filename)
+	key := lang.instanceKey(root)
+
 	// existing running instance
-	if lang.li.li != nil {
-		return lang.li.li, nil
+	if lic, ok := lang.li.m[key]; ok {
+		return lic.li, root, nil
 	}
 
 	// setup instance context
@@ -37,14 +68,14 @@ func (lang *LangManager) instance(startCtx context.Context) (*LangInstance, erro
 	stop := context.AfterFunc(startCtx, cancel)
 	defer stop()
 
-	li, err := NewLangInstance(ctx, lang)
+	li, err := NewLangInstance(ctx, lang, root)
 	if err != nil {
 		cancel()
 		err = lang.WrapError(err)
-		return nil, err
+		return nil, "", err
 	}
-	lang.li.li = li
-	lang.li.cancel = cancel
+	lic := &langInstanceCancel{li: li, cancel: cancel}
+	lang.li.m[key] = lic
 
 	// clear instance var on exit
 	go func() {
@@ -55,30 +86,77 @@ func (lang *LangManager) instance(startCtx context.Context) (*LangInstance, erro
 		// ensure correct instance is cleared
 		lang.li.Lock()
 		defer lang.li.Unlock()
-		if lang.li.li == li {
-			lang.li.li = nil
+		if lang.li.m[key] == lic {
+			delete(lang.li.m, key)
 		}
 	}()
 
-	return li, nil
+	return li, root, nil
+}
+
+func (lang *LangManager) nInstances() int {
+	lang.li.Lock()
+	defer lang.li.Unlock()
+	return len(lang.li.m)
+}
+
+// returns the running instance for the filename, without starting one
+func (lang *LangManager) currentInstance(filename string) (*LangInstance, bool) {
+	lang.li.Lock()
+	defer lang.li.Unlock()
+	key := lang.instanceKey(lang.root(filename))
+	lic, ok := lang.li.m[key]
+	if !ok {
+		return nil, false
+	}
+	return lic.li, true
 }
 
-func (lang *LangManager) hasInstance() bool {
+// running instances, sorted by root
+func (lang *LangManager) instances() []*LangInstance {
 	lang.li.Lock()
 	defer lang.li.Unlock()
-	return lang.li.li != nil
+	res := []*LangInstance{}
+	for _, lic := range lang.li.m {
+		res = append(res, lic.li)
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].root < res[b].root
+	})
+	return res
 }
 
-// returns true if the instance was running
-func (lang *LangManager) stopInstance() bool {
+// returns true if any instance was running
+func (lang *LangManager) stopInstances() bool {
 	lang.li.Lock()
 	defer lang.li.Unlock()
-	if lang.li.li != nil {
-		lang.li.cancel()
-		lang.li.li = nil
-		return true
+	stopped := len(lang.li.m) > 0
+	for _, lic := range lang.li.m {
+		lic.cancel()
 	}
-	return false
+	lang.li.m = map[string]*langInstanceCancel{}
+	lang.li.roots = map[string]string{} // allow detecting new roots
+	return stopped
+}
+
+//----------
+
+// Must have the lock.
+func (lang *LangManager) root(filename string) string {
+	dir := filepath.Dir(filename)
+	if root, ok := lang.li.roots[dir]; ok {
+		return root
+	}
+	root := findRoot(dir, lang.Reg.RootMarkers())
+	lang.li.roots[dir] = root
+	return root
+}
+
+func (lang *LangManager) instanceKey(root string) string {
+	if lang.Reg.HasOptional("sharedroots") {
+		return ""
+	}
+	return root
 }
 
 //----------
@@ -94,3 +172,22 @@ func (lang *LangManager) WrapError(err error) error {
 func (lang *LangManager) WrapMsg(s string) string {
 	return fmt.Sprintf("lsproto(%s): %v", lang.Reg.Language, s)
 }
+
+//----------
+
+// Returns the closest dir (from dir up) containing the first found marker, checking the markers in order. Returns "" if no marker is found.
+func findRoot(dir string, markers []string) string {
+	for _, m := range markers {
+		for d := dir; ; {
+			if _, err := os.Stat(filepath.Join(d, m)); err == nil {
+				return d
+			}
+			d2 := filepath.Dir(d)
+			if d2 == d {
+				break
+			}
+			d = d2
+		}
+	}
+	return ""
+}
@@ -7,7 +7,10 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 
+	"github.com/jmigpin/editor/util/iout"
 	"github.com/jmigpin/editor/util/iout/iorw"
 )
 
@@ -15,18 +18,55 @@ import (
 
 // Notes:
 // - Manager manages LangManagers
-// - LangManager has a Registration and handles a LangInstance
+// - LangManager has a Registration and handles a LangInstance per project root
+// - several LangManagers can handle the same file ext, the first is the primary and the others have some results merged
 // - Client handles client connection to the lsp server
 // - ServerWrap, if used, runs the lsp server process
 type Manager struct {
 	langs []*LangManager
 	msgFn func(string)
 
+	// called when the diagnostics of a file change (not UI safe)
+	OnDiagnostics func(filename string)
+
+	diags struct {
+		sync.Mutex
+		m map[string]map[*LangManager][]*Diagnostic // key is filename
+	}
+
+	// applies workspace edit changes to open documents (ex: editor rows), returns the changes not applied, to be patched in the files (not UI safe)
+	ApplyOpenEditsFn func([]*WorkspaceEditChange) ([]*WorkspaceEditChange, error)
+
+	codeActions struct {
+		sync.Mutex
+		id int                        // last id given
+		m  map[int]*ManagerCodeAction // last listed actions
+	}
+
+	// called when a work done progress begins/reports/ends (not UI safe)
+	OnProgress func(*ManagerProgress)
+
+	progress struct {
+		sync.Mutex
+		id int                         // last client token id given
+		m  map[string]*ManagerProgress // key is the token
+	}
+
+	traces struct {
+		sync.Mutex
+		id int
+		m  map[int]*managerTrace
+	}
+
 	serverWrapW io.Writer // test purposes only
 }
 
 func NewManager(msgFn func(string)) *Manager {
-	return &Manager{msgFn: msgFn}
+	man := &Manager{msgFn: msgFn}
+	man.diags.m = map[string]map[*LangManager][]*Diagnostic{}
+	man.progress.m = map[string]*ManagerProgress{}
+	man.traces.m = map[int]*managerTrace{}
+	return man
 }
 
 //----------
@@ -59,16 +99,40 @@ func (man *Manager) Register(reg *Registration) error {
 
 //----------
 
+// Returns the primary lang manager for the filename.
 func (man *Manager) LangManager(filename string) (*LangManager, error) {
+	langs, err := man.langManagers(filename)
+	if err != nil {
+		return nil, err
+	}
+	return langs[0], nil
+}
+
+// Returns all the lang managers that handle the filename, in registration order.
+func (man *Manager) langManagers(filename string) ([]*LangManager, error) {
 	ext := filepath.Ext(filename)
+	res := []*LangManager{}
 	for _, lang := range man.langs {
 		for _, ext2 := range lang.Reg.Exts {
 			if ext2 == ext {
-				return lang, nil
+				res = append(res, lang)
+				break
 			}
 		}
 	}
-	return nil, fmt.Errorf("no lsproto for file ext: %q", ext)
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no lsproto for file ext: %q", ext)
+	}
+	return res, nil
+}
+
+func (man *Manager) langManagerByLanguage(language string) (*LangManager, bool) {
+	for _, lang := range man.langs {
+		if lang.Reg.Language == language {
+			return lang, true
+		}
+	}
+	return nil, false
 }
 
 func (man *Manager) langInstanceClient(ctx context.Context, filename string) (*Client, *LangInstance, error) {
@@ -76,126 +140,251 @@ func (man *Manager) langInstanceClient(ctx context.Context, filename string) (*C
 	if err != nil {
 		return nil, nil, err
 	}
-	li, err := lang.instance(ctx)
+	li, err := lang.instance(ctx, filename)
 	if err != nil {
 		return nil, nil, err
 	}
 	return li.cli, li, nil
 }
 
+// Returns the clients of all the lang managers that handle the filename (the first is the primary). Fails only if the primary fails, the other errors are reported as messages.
+func (man *Manager) langInstanceClients(ctx context.Context, filename string) ([]*Client, error) {
+	langs, err := man.langManagers(filename)
+	if err != nil {
+		return nil, err
+	}
+	clis := []*Client{}
+	for i, lang := range langs {
+		li, err := lang.instance(ctx, filename)
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			man.Error(err)
+			continue
+		}
+		clis = append(clis, li.cli)
+	}
+	return clis, nil
+}
+
 //----------
 
 func (man *Manager) NInstances() int {
 	c := 0
 	for _, lang := range man.langs {
-		if lang.hasInstance() {
-			c++
-		}
+		c += lang.nInstances()
 	}
 	return c
 }
 
 func (man *Manager) Stop() {
 	for _, lang := range man.langs {
-		if ok := lang.stopInstance(); ok {
+		if ok := lang.stopInstances(); ok {
 			man.Message(lang.WrapMsg("stopped"))
 		}
 	}
+	man.clearDiagnostics()
+	man.clearProgress()
 }
 
 //----------
 
-func (man *Manager) TextDocumentImplementation(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) ([]*Location, error) {
-	cli, _, err := man.langInstanceClient(ctx, filename)
+func (man *Manager) setDiagnostics(lang *LangManager, pdp *PublishDiagnosticsParams) error {
+	filename, err := UrlToAbsFilename(string(pdp.Uri))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	didCloseFn, err := man.didOpen(ctx, cli, filename, rd)
-	if err != nil {
-		return nil, err
+	man.diags.Lock()
+	m := man.diags.m[filename]
+	if len(pdp.Diagnostics) == 0 {
+		delete(m, lang)
+		if len(m) == 0 {
+			delete(man.diags.m, filename)
+		}
+	} else {
+		if m == nil {
+			m = map[*LangManager][]*Diagnostic{}
+			man.diags.m[filename] = m
+		}
+		m[lang] = pdp.Diagnostics
 	}
-	defer didCloseFn()
+	man.diags.Unlock()
 
-	pos, err := OffsetToPosition(rd, offset)
-	if err != nil {
-		return nil, err
+	man.onDiagnostics(filename)
+	return nil
+}
+
+func (man *Manager) clearDiagnostics() {
+	man.diags.Lock()
+	filenames := []string{}
+	for k := range man.diags.m {
+		filenames = append(filenames, k)
+	}
+	man.diags.m = map[string]map[*LangManager][]*Diagnostic{}
+	man.diags.Unlock()
+
+	for _, filename := range filenames {
+		man.onDiagnostics(filename)
 	}
+}
+
+func (man *Manager) onDiagnostics(filename string) {
+	if man.OnDiagnostics != nil {
+		man.OnDiagnostics(filename)
+	}
+}
+
+// Merged diagnostics of all the lang managers, in registration order.
+func (man *Manager) Diagnostics(filename string) []*Diagnostic {
+	man.diags.Lock()
+	defer man.diags.Unlock()
+	return man.fileDiagnostics(filename)
+}
+
+func (man *Manager) langDiagnostics(lang *LangManager, filename string) []*Diagnostic {
+	man.diags.Lock()
+	defer man.diags.Unlock()
+	return man.diags.m[filename][lang]
+}
 
-	return cli.TextDocumentImplementation(ctx, filename, pos)
+// Sorted by filename.
+func (man *Manager) AllDiagnostics() []*FileDiagnostics {
+	man.diags.Lock()
+	defer man.diags.Unlock()
+	res := []*FileDiagnostics{}
+	for k := range man.diags.m {
+		res = append(res, &FileDiagnostics{k, man.fileDiagnostics(k)})
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].Filename < res[b].Filename
+	})
+	return res
+}
+
+// Must have the lock.
+func (man *Manager) fileDiagnostics(filename string) []*Diagnostic {
+	m := man.diags.m[filename]
+	if len(m) == 1 { // common case
+		for _, v := range m {
+			return v
+		}
+	}
+	res := []*Diagnostic{}
+	for _, lang := range man.langs {
+		res = append(res, m[lang]...)
+	}
+	return res
+}
+
+//----------
+
+func (man *Manager) TextDocumentImplementation(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) ([]*Location, error) {
+	return man.primaryLocations(ctx, filename, rd, offset, func(cli *Client, pos Position) ([]*Location, error) {
+		return cli.TextDocumentImplementation(ctx, filename, pos)
+	})
 }
 
 //----------
 
 func (man *Manager) TextDocumentDefinition(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) ([]*Location, error) {
+	return man.primaryLocations(ctx, filename, rd, offset, func(cli *Client, pos Position) ([]*Location, error) {
+		return cli.TextDocumentDefinition(ctx, filename, pos)
+	})
+}
+
+//----------
+
+func (man *Manager) TextDocumentTypeDefinition(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) ([]*Location, error) {
+	return man.primaryLocations(ctx, filename, rd, offset, func(cli *Client, pos Position) ([]*Location, error) {
+		return cli.TextDocumentTypeDefinition(ctx, filename, pos)
+	})
+}
+
+func (man *Manager) TextDocumentDeclaration(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) ([]*Location, error) {
+	return man.primaryLocations(ctx, filename, rd, offset, func(cli *Client, pos Position) ([]*Location, error) {
+		return cli.TextDocumentDeclaration(ctx, filename, pos)
+	})
+}
+
+//----------
+
+func (man *Manager) TextDocumentCompletion(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) (*CompletionList, error) {
 	cli, _, err := man.langInstanceClient(ctx, filename)
 	if err != nil {
 		return nil, err
 	}
 
-	didCloseFn, err := man.didOpen(ctx, cli, filename, rd)
-	if err != nil {
+	if err := cli.syncDocument(ctx, filename, rd); err != nil {
 		return nil, err
 	}
-	defer didCloseFn()
 
 	pos, err := OffsetToPosition(rd, offset)
 	if err != nil {
 		return nil, err
 	}
 
-	return cli.TextDocumentDefinition(ctx, filename, pos)
+	return cli.TextDocumentCompletion(ctx, filename, pos)
+}
+
+func (man *Manager) TextDocumentCompletionDetailStrings(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) ([]string, error) {
+	clist, err := man.TextDocumentCompletion(ctx, filename, rd, offset)
+	if err != nil {
+		return nil, err
+	}
+	w := CompletionListToString(clist)
+	return w, nil
 }
 
 //----------
 
-func (man *Manager) TextDocumentCompletion(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) (*CompletionList, error) {
+// Returns nil if there is no information at the offset.
+func (man *Manager) TextDocumentHover(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) (*Hover, error) {
 	cli, _, err := man.langInstanceClient(ctx, filename)
 	if err != nil {
 		return nil, err
 	}
 
-	didCloseFn, err := man.didOpen(ctx, cli, filename, rd)
-	if err != nil {
+	if err := cli.syncDocument(ctx, filename, rd); err != nil {
 		return nil, err
 	}
-	defer didCloseFn()
 
 	pos, err := OffsetToPosition(rd, offset)
 	if err != nil {
 		return nil, err
 	}
 
-	return cli.TextDocumentCompletion(ctx, filename, pos)
+	return cli.TextDocumentHover(ctx, filename, pos)
 }
 
-func (man *Manager) TextDocumentCompletionDetailStrings(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) ([]string, error) {
-	clist, err := man.TextDocumentCompletion(ctx, filename, rd, offset)
+func (man *Manager) TextDocumentHoverString(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) (string, error) {
+	h, err := man.TextDocumentHover(ctx, filename, rd, offset)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	w := CompletionListToString(clist)
-	return w, nil
+	return HoverToString(h), nil
 }
 
 //----------
 
-func (man *Manager) didOpen(ctx context.Context, cli *Client, filename string, rd iorw.ReaderAt) (func(), error) {
-	b, err := iorw.ReadFastFull(rd)
+// Returns nil if there is no signature at the offset.
+func (man *Manager) TextDocumentSignatureHelp(ctx context.Context, filename string, rd iorw.ReaderAt, offset int, triggerChar string) (*SignatureHelp, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
 	if err != nil {
 		return nil, err
 	}
-	if err := cli.TextDocumentDidOpenVersion(ctx, filename, b); err != nil {
+
+	if err := cli.syncDocument(ctx, filename, rd); err != nil {
 		return nil, err
 	}
 
-	// ISSUE: file1 src is sent to the server (didopen). Assume now that the request that follows (ex: lsprotoCallers) takes too long such that the ctx expires. The usual "defer didclose" will fail since the context is no longer valid. And so the server stays with the version that might have compile errors. The user corrects the errors without asking anything else from the lspserver. Later on, on another file2, asks for the lspserver to assist with something. This could fail since the lspserver still has the file1 cached with errors.
-	// solution: if the didopen was successful, return a func to always run the didClose with defer even if the ctx is no longer valid.
-	didCloseFn := func() {
-		ctx2 := context.Background()                 // don't use a possible canceled ctx
-		_ = cli.TextDocumentDidClose(ctx2, filename) // best effort, ignore error
+	pos, err := OffsetToPosition(rd, offset)
+	if err != nil {
+		return nil, err
 	}
-	return didCloseFn, nil
+
+	return cli.TextDocumentSignatureHelp(ctx, filename, pos, triggerChar)
 }
 
 //----------
@@ -219,20 +408,19 @@ func (man *Manager) didOpen(ctx context.Context, cli *Client, filename string, r
 
 //----------
 
+// Syncs the saved content with all the servers that handle the filename (ex: a secondary linter server only reports diagnostics on open/save).
 func (man *Manager) SyncText(ctx context.Context, filename string, rd iorw.ReaderAt) error {
-	cli, _, err := man.langInstanceClient(ctx, filename)
+	clis, err := man.langInstanceClients(ctx, filename)
 	if err != nil {
 		return err
 	}
 
-	// opening/closing is enough to give the content to the server (using a didsave/didchange would just make it slower since our strategy is to open/close for every change)
-	didCloseFn, err := man.didOpen(ctx, cli, filename, rd)
-	if err != nil {
-		return err
+	// the document is kept open, only the differences are sent
+	me := iout.MultiError{}
+	for _, cli := range clis {
+		me.Add(cli.saveDocument(ctx, filename, rd))
 	}
-	defer didCloseFn()
-
-	return nil
+	return me.Result()
 }
 
 //----------
@@ -243,11 +431,9 @@ func (man *Manager) TextDocumentRename(ctx context.Context, filename string, rd
 		return nil, err
 	}
 
-	didCloseFn, err := man.didOpen(ctx, cli, filename, rd)
-	if err != nil {
+	if err := cli.syncDocument(ctx, filename, rd); err != nil {
 		return nil, err
 	}
-	defer didCloseFn()
 
 	pos, err := OffsetToPosition(rd, offset)
 	if err != nil {
@@ -275,41 +461,366 @@ func (man *Manager) TextDocumentRenameAndPatch(ctx context.Context, filename str
 		}
 	}
 
+	if err := man.patchFiles(ctx, wecs); err != nil {
+		return nil, err
+	}
+
+	return wecs, nil
+}
+
+func (man *Manager) patchFiles(ctx context.Context, wecs []*WorkspaceEditChange) error {
 	// two or more changes to the same file can give trouble (don't using concurrency for this)
 	for _, wec := range wecs {
 		filename := wec.Filename
 		b, err := ioutil.ReadFile(filename)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		res, err := PatchTextEdits(b, wec.Edits)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if err := os.WriteFile(filename, res, 0o644); err != nil {
-			return nil, err
+			return err
 		}
-		if err := man.SyncText(ctx, filename, rd); err != nil {
-			return nil, err
+		if err := man.syncPatchedFile(ctx, filename, res); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	return wecs, nil
+func (man *Manager) syncPatchedFile(ctx context.Context, filename string, b []byte) error {
+	clis, err := man.langInstanceClients(ctx, filename)
+	if err != nil {
+		return err
+	}
+	me := iout.MultiError{}
+	for _, cli := range clis {
+		// documents kept open will be updated with the writes from the editor reload
+		if cli.hasDocument(filename) {
+			continue
+		}
+		// give the new content to the server
+		rd := iorw.NewBytesReadWriterAt(b)
+		if err := cli.saveDocument(ctx, filename, rd); err != nil {
+			me.Add(err)
+			continue
+		}
+		me.Add(cli.closeDocument(ctx, filename))
+	}
+	return me.Result()
 }
 
 //----------
 
-func (man *Manager) CallHierarchyCalls(ctx context.Context, filename string, rd iorw.ReaderAt, offset int, typ CallHierarchyCallType) ([]*ManagerCallHierarchyCalls, error) {
+// Sends the write to the server if the document is open (not UI safe; must be called in the order of the writes).
+func (man *Manager) DocumentWrite(filename string, rd iorw.ReaderAt, ev *iorw.RWEvWrite) error {
+	langs, err := man.langManagers(filename)
+	if err != nil {
+		return nil // no lang registered
+	}
+	p := []byte(nil)
+	for _, lang := range langs {
+		li, ok := lang.currentInstance(filename)
+		if !ok {
+			continue
+		}
+		if !li.cli.hasDocument(filename) {
+			continue
+		}
+		if p == nil {
+			b, err := rd.ReadFastAt(ev.Index, ev.In)
+			if err != nil {
+				return err
+			}
+			p = append([]byte{}, b...) // rd content can change before the flush
+		}
+		li.cli.documentWrite(filename, ev.Index, ev.Dn, p)
+	}
+	return nil
+}
+
+// Closes the document if open in the server.
+func (man *Manager) DocumentClose(ctx context.Context, filename string) error {
+	langs, err := man.langManagers(filename)
+	if err != nil {
+		return nil // no lang registered
+	}
+	me := iout.MultiError{}
+	for _, lang := range langs {
+		if li, ok := lang.currentInstance(filename); ok {
+			me.Add(li.cli.closeDocument(ctx, filename))
+		}
+	}
+	return me.Result()
+}
+
+//----------
+
+// Formats the whole document if n<0. Returns the edits to be applied to rd.
+func (man *Manager) TextDocumentFormatting(ctx context.Context, filename string, rd iorw.ReaderAt, offset, n int) ([]*TextEdit, error) {
 	cli, _, err := man.langInstanceClient(ctx, filename)
 	if err != nil {
 		return nil, err
 	}
 
-	didCloseFn, err := man.didOpen(ctx, cli, filename, rd)
+	if err := cli.syncDocument(ctx, filename, rd); err != nil {
+		return nil, err
+	}
+
+	opts := FormattingOptions{TabSize: 8, InsertSpaces: false}
+
+	if n < 0 {
+		return cli.TextDocumentFormatting(ctx, filename, opts)
+	}
+
+	start, err := OffsetToPosition(rd, offset)
+	if err != nil {
+		return nil, err
+	}
+	end, err := OffsetToPosition(rd, offset+n)
+	if err != nil {
+		return nil, err
+	}
+	rang := Range{Start: start, End: end}
+	return cli.TextDocumentRangeFormatting(ctx, filename, rang, opts)
+}
+
+// Formats the whole content, returning the formatted content.
+func (man *Manager) TextDocumentFormattingBytes(ctx context.Context, filename string, b []byte) ([]byte, error) {
+	rd := iorw.NewBytesReadWriterAt(b)
+	edits, err := man.TextDocumentFormatting(ctx, filename, rd, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	return PatchTextEdits(b, edits)
+}
+
+//----------
+
+// Returns the inlay hints for the range (offset, n), ordered by offset.
+func (man *Manager) TextDocumentInlayHints(ctx context.Context, filename string, rd iorw.ReaderAt, offset, n int) ([]*ManagerInlayHint, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
 	if err != nil {
 		return nil, err
 	}
-	defer didCloseFn()
+
+	if err := cli.syncDocument(ctx, filename, rd); err != nil {
+		return nil, err
+	}
+
+	start, err := OffsetToPosition(rd, offset)
+	if err != nil {
+		return nil, err
+	}
+	end, err := OffsetToPosition(rd, offset+n)
+	if err != nil {
+		return nil, err
+	}
+	rang := Range{Start: start, End: end}
+	hints, err := cli.TextDocumentInlayHint(ctx, filename, rang)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*ManagerInlayHint{}
+	for _, h := range hints {
+		o, _, err := RangeToOffsetLen(rd, &Range{Start: h.Position, End: h.Position})
+		if err != nil {
+			continue // hint for a different version of the content
+		}
+		mh := &ManagerInlayHint{Offset: o, Label: InlayHintLabel(h), Kind: h.Kind}
+		res = append(res, mh)
+	}
+	sort.SliceStable(res, func(a, b int) bool {
+		return res[a].Offset < res[b].Offset
+	})
+	return res, nil
+}
+
+//----------
+
+// Returns the decoded semantic tokens of the document, ordered by offset. The offsets refer to the content of rd at the time of the request.
+func (man *Manager) TextDocumentSemanticTokens(ctx context.Context, filename string, rd iorw.ReaderAt) ([]*SemanticToken, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cli.syncDocument(ctx, filename, rd); err != nil {
+		return nil, err
+	}
+
+	text, data, err := cli.semanticTokens(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+	legend := &cli.serverCapabilities.semanticTokens.legend
+	return decodeSemanticTokens(text, data, legend), nil
+}
+
+//----------
+
+func (man *Manager) TextDocumentDocumentSymbol(ctx context.Context, filename string, rd iorw.ReaderAt) ([]*DocumentSymbol, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cli.syncDocument(ctx, filename, rd); err != nil {
+		return nil, err
+	}
+
+	return cli.TextDocumentDocumentSymbol(ctx, filename)
+}
+
+// The filename is used to choose the lsp instance.
+func (man *Manager) WorkspaceSymbol(ctx context.Context, filename string, query string) ([]*SymbolInformation, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+	tok, done := man.workDoneProgress(cli, filename)
+	defer done()
+	return cli.WorkspaceSymbol(ctx, query, tok)
+}
+
+//----------
+
+// Lists the code actions for the range (offset, n). The actions are kept to be applied later by id.
+func (man *Manager) TextDocumentCodeActions(ctx context.Context, filename string, rd iorw.ReaderAt, offset, n int) ([]*ManagerCodeAction, error) {
+	clis, err := man.langInstanceClients(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := OffsetToPosition(rd, offset)
+	if err != nil {
+		return nil, err
+	}
+	end, err := OffsetToPosition(rd, offset+n)
+	if err != nil {
+		return nil, err
+	}
+	rang := Range{Start: start, End: end}
+
+	// merge the actions of all servers
+	type cliActions struct {
+		cli     *Client
+		actions []*CodeAction
+	}
+	cas := []*cliActions{}
+	for i, cli := range clis {
+		actions, err := man.textDocumentCodeActions(ctx, cli, filename, rd, rang)
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			continue // ignore errors from non-primary servers
+		}
+		cas = append(cas, &cliActions{cli, actions})
+	}
+
+	man.codeActions.Lock()
+	defer man.codeActions.Unlock()
+	man.codeActions.m = map[int]*ManagerCodeAction{}
+	res := []*ManagerCodeAction{}
+	for _, u := range cas {
+		for _, ca := range u.actions {
+			man.codeActions.id++
+			mca := &ManagerCodeAction{Id: man.codeActions.id, Filename: filename, Action: ca, cli: u.cli}
+			man.codeActions.m[mca.Id] = mca
+			res = append(res, mca)
+		}
+	}
+	return res, nil
+}
+
+func (man *Manager) textDocumentCodeActions(ctx context.Context, cli *Client, filename string, rd iorw.ReaderAt, rang Range) ([]*CodeAction, error) {
+	if err := cli.syncDocument(ctx, filename, rd); err != nil {
+		return nil, err
+	}
+
+	// diagnostics (from the same server) in the range allow the server to give quick fixes
+	diags := []*Diagnostic{}
+	for _, d := range man.langDiagnostics(cli.li.lang, filename) {
+		if d.Range != nil && rangesOverlap(d.Range, &rang) {
+			diags = append(diags, d)
+		}
+	}
+
+	return cli.TextDocumentCodeAction(ctx, filename, rang, diags)
+}
+
+// Applies a code action previously listed with TextDocumentCodeActions. Resolves the action if needed, patches the files with the action edit, and executes the action command (the server can then request more edits).
+func (man *Manager) CodeActionApply(ctx context.Context, id int) error {
+	man.codeActions.Lock()
+	mca, ok := man.codeActions.m[id]
+	man.codeActions.Unlock()
+	if !ok {
+		return fmt.Errorf("code action not found (list the actions again): %v", id)
+	}
+
+	cli := mca.cli // server that listed the action
+	if cli == nil {
+		cli2, _, err := man.langInstanceClient(ctx, mca.Filename)
+		if err != nil {
+			return err
+		}
+		cli = cli2
+	}
+
+	ca := mca.Action
+	if ca.Edit == nil && ca.Command == nil && cli.serverCapabilities.codeActionResolve {
+		ca2, err := cli.CodeActionResolve(ctx, ca)
+		if err != nil {
+			return err
+		}
+		ca = ca2
+	}
+
+	if ca.Edit != nil {
+		if err := man.applyWorkspaceEdit(ctx, ca.Edit); err != nil {
+			return err
+		}
+	}
+	if ca.Command != nil {
+		if err := cli.WorkspaceExecuteCommand(ctx, ca.Command); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//----------
+
+func (man *Manager) applyWorkspaceEdit(ctx context.Context, we *WorkspaceEdit) error {
+	wecs, err := we.GetChanges()
+	if err != nil {
+		return err
+	}
+	if man.ApplyOpenEditsFn != nil {
+		wecs2, err := man.ApplyOpenEditsFn(wecs)
+		if err != nil {
+			return err
+		}
+		wecs = wecs2
+	}
+	return man.patchFiles(ctx, wecs)
+}
+
+//----------
+
+func (man *Manager) CallHierarchyCalls(ctx context.Context, filename string, rd iorw.ReaderAt, offset int, typ CallHierarchyCallType) ([]*ManagerCallHierarchyCalls, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cli.syncDocument(ctx, filename, rd); err != nil {
+		return nil, err
+	}
 
 	pos, err := OffsetToPosition(rd, offset)
 	if err != nil {
@@ -326,7 +837,9 @@ func (man *Manager) CallHierarchyCalls(ctx context.Context, filename string, rd
 
 	res := []*ManagerCallHierarchyCalls{}
 	for _, item := range items {
-		calls, err := cli.CallHierarchyCalls(ctx, typ, item)
+		tok, done := man.workDoneProgress(cli, filename)
+		calls, err := cli.CallHierarchyCalls(ctx, typ, item, tok)
+		done()
 		if err != nil {
 			return nil, err
 		}
@@ -339,22 +852,106 @@ func (man *Manager) CallHierarchyCalls(ctx context.Context, filename string, rd
 
 //----------
 
+func (man *Manager) TypeHierarchyTypes(ctx context.Context, filename string, rd iorw.ReaderAt, offset int, typ TypeHierarchyType) ([]*ManagerTypeHierarchyTypes, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cli.syncDocument(ctx, filename, rd); err != nil {
+		return nil, err
+	}
+
+	pos, err := OffsetToPosition(rd, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := cli.TextDocumentPrepareTypeHierarchy(ctx, filename, pos)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("preparetypehierarchy returned no items")
+	}
+
+	res := []*ManagerTypeHierarchyTypes{}
+	for _, item := range items {
+		tok, done := man.workDoneProgress(cli, filename)
+		types, err := cli.TypeHierarchyTypes(ctx, typ, item, tok)
+		done()
+		if err != nil {
+			return nil, err
+		}
+		u := &ManagerTypeHierarchyTypes{item, types}
+		res = append(res, u)
+	}
+
+	return res, nil
+}
+
+//----------
+
 func (man *Manager) TextDocumentReferences(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) ([]*Location, error) {
+	return man.mergedLocations(ctx, filename, rd, offset, func(cli *Client, pos Position) ([]*Location, error) {
+		tok, done := man.workDoneProgress(cli, filename)
+		defer done()
+		return cli.TextDocumentReferences(ctx, filename, pos, tok)
+	})
+}
+
+//----------
+
+// Locations from the primary server only (single results: the other servers are not started).
+func (man *Manager) primaryLocations(ctx context.Context, filename string, rd iorw.ReaderAt, offset int, fn func(*Client, Position) ([]*Location, error)) ([]*Location, error) {
 	cli, _, err := man.langInstanceClient(ctx, filename)
 	if err != nil {
 		return nil, err
 	}
+	if err := cli.syncDocument(ctx, filename, rd); err != nil {
+		return nil, err
+	}
+	pos, err := OffsetToPosition(rd, offset)
+	if err != nil {
+		return nil, err
+	}
+	return fn(cli, pos)
+}
 
-	didCloseFn, err := man.didOpen(ctx, cli, filename, rd)
+// Merges the locations of all the servers that handle the filename, without duplicates. Errors from servers other than the primary are ignored (ex: method not supported).
+func (man *Manager) mergedLocations(ctx context.Context, filename string, rd iorw.ReaderAt, offset int, fn func(*Client, Position) ([]*Location, error)) ([]*Location, error) {
+	clis, err := man.langInstanceClients(ctx, filename)
 	if err != nil {
 		return nil, err
 	}
-	defer didCloseFn()
 
 	pos, err := OffsetToPosition(rd, offset)
 	if err != nil {
 		return nil, err
 	}
 
-	return cli.TextDocumentReferences(ctx, filename, pos)
+	res := []*Location{}
+	seen := map[string]bool{}
+	for i, cli := range clis {
+		locs, err := func() ([]*Location, error) {
+			if err := cli.syncDocument(ctx, filename, rd); err != nil {
+				return nil, err
+			}
+			return fn(cli, pos)
+		}()
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			continue
+		}
+		for _, loc := range locs {
+			k := fmt.Sprintf("%v %+v", loc.Uri, loc.Range)
+			if !seen[k] {
+				seen[k] = true
+				res = append(res, loc)
+			}
+		}
+	}
+	return res, nil
 }
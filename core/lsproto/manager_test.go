@@ -1,22 +1,27 @@
 package lsproto
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/jmigpin/editor/util/iout"
 	"github.com/jmigpin/editor/util/iout/iorw"
 	"github.com/jmigpin/editor/util/parseutil"
 	"github.com/jmigpin/editor/util/testutil"
+	"golang.org/x/exp/jsonrpc2"
 )
 
 func TestStruct1(t *testing.T) {
@@ -45,10 +50,700 @@ func TestStruct1(t *testing.T) {
 
 }
 
+func TestDiagnostics1(t *testing.T) {
+	msg := `{"uri":"file:///a/b.go","diagnostics":[
+		{"range":{"start":{"line":4,"character":1},"end":{"line":4,"character":3}},"severity":2,"source":"vet","message":"msg2"},
+		{"range":{"start":{"line":1,"character":0},"end":{"line":1,"character":3}},"severity":1,"message":"msg1\nmore"}
+	]}`
+	pdp := &PublishDiagnosticsParams{}
+	if err := json.Unmarshal([]byte(msg), pdp); err != nil {
+		t.Fatal(err)
+	}
+
+	man := NewManager(nil)
+	lang := NewLangManager(man, &Registration{Language: "go"})
+	updated := ""
+	man.OnDiagnostics = func(filename string) {
+		updated = filename
+	}
+	if err := man.setDiagnostics(lang, pdp); err != nil {
+		t.Fatal(err)
+	}
+	if updated != "/a/b.go" {
+		t.Fatal(updated)
+	}
+
+	s := DiagnosticsToString(man.AllDiagnostics(), "/a")
+	s2 := "\tb.go:2:1: error: msg1\n" +
+		"\tb.go:5:2: warning: msg2 (vet)\n"
+	if s != s2 {
+		t.Fatalf("%q", s)
+	}
+
+	// empty diagnostics clears the file entry
+	pdp.Diagnostics = nil
+	if err := man.setDiagnostics(lang, pdp); err != nil {
+		t.Fatal(err)
+	}
+	if len(man.AllDiagnostics()) != 0 {
+		t.Fatal("expecting no diagnostics")
+	}
+}
+
+func TestDiagnostics2(t *testing.T) {
+	// two servers for the same file ext
+	man := NewManager(nil)
+	_ = man.Register(&Registration{Language: "go", Exts: []string{".go"}})
+	_ = man.Register(&Registration{Language: "golint", Exts: []string{".go"}})
+	langs, err := man.langManagers("/a/b.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(langs) != 2 {
+		t.Fatal(len(langs))
+	}
+
+	set := func(lang *LangManager, msg string) {
+		t.Helper()
+		pdp := &PublishDiagnosticsParams{}
+		if err := json.Unmarshal([]byte(msg), pdp); err != nil {
+			t.Fatal(err)
+		}
+		if err := man.setDiagnostics(lang, pdp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	set(langs[1], `{"uri":"file:///a/b.go","diagnostics":[{"range":{"start":{"line":2,"character":0},"end":{"line":2,"character":1}},"severity":2,"message":"lint1"}]}`)
+	set(langs[0], `{"uri":"file:///a/b.go","diagnostics":[{"range":{"start":{"line":0,"character":0},"end":{"line":0,"character":1}},"severity":1,"message":"err1"}]}`)
+
+	s := DiagnosticsToString(man.AllDiagnostics(), "/a")
+	s2 := "\tb.go:1:1: error: err1\n" +
+		"\tb.go:3:1: warning: lint1\n"
+	if s != s2 {
+		t.Fatalf("%q", s)
+	}
+	if n := len(man.langDiagnostics(langs[1], "/a/b.go")); n != 1 {
+		t.Fatal(n)
+	}
+
+	// clearing one server keeps the other
+	set(langs[0], `{"uri":"file:///a/b.go","diagnostics":[]}`)
+	if d := man.Diagnostics("/a/b.go"); len(d) != 1 || d[0].Message != "lint1" {
+		t.Fatal(d)
+	}
+}
+
+func TestDiagnostics3(t *testing.T) {
+	// diagnostic without a range
+	fdiags := []*FileDiagnostics{{
+		Filename: "/a/b.go",
+		Diagnostics: []*Diagnostic{
+			{Range: &Range{Start: Position{Line: 1}}, Severity: 1, Message: "msg1"},
+			{Severity: 2, Message: "msg2"},
+		},
+	}}
+	s := DiagnosticsToString(fdiags, "/a")
+	s2 := "\tb.go: warning: msg2\n" +
+		"\tb.go:2:1: error: msg1\n"
+	if s != s2 {
+		t.Fatalf("%q", s)
+	}
+}
+
+func TestFindRoot1(t *testing.T) {
+	dir := t.TempDir()
+	mk := func(name string) {
+		t.Helper()
+		filename := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mk("go.work")
+	mk("m1/go.mod")
+	mk("m1/pkg/a.go")
+	mk("m2/go.mod")
+
+	m1 := filepath.Join(dir, "m1")
+	pkg := filepath.Join(m1, "pkg")
+	if r := findRoot(pkg, []string{"go.mod"}); r != m1 {
+		t.Fatal(r)
+	}
+	// first marker has preference
+	if r := findRoot(pkg, []string{"go.work", "go.mod"}); r != dir {
+		t.Fatal(r)
+	}
+	if r := findRoot(pkg, []string{"compile_commands.json"}); r != "" {
+		t.Fatal(r)
+	}
+
+	// one instance per root, unless sharing roots
+	man := NewManager(nil)
+	lang := NewLangManager(man, &Registration{Language: "a", Optional: []string{"root:go.mod"}})
+	lang.li.Lock()
+	k1 := lang.instanceKey(lang.root(filepath.Join(pkg, "a.go")))
+	k2 := lang.instanceKey(lang.root(filepath.Join(dir, "m2", "b.go")))
+	lang.li.Unlock()
+	if k1 != m1 || k2 != filepath.Join(dir, "m2") {
+		t.Fatal(k1, k2)
+	}
+	lang.Reg.Optional = append(lang.Reg.Optional, "sharedroots")
+	if k := lang.instanceKey(m1); k != "" {
+		t.Fatal(k)
+	}
+}
+
+func TestHover1(t *testing.T) {
+	msgs := []string{
+		`{"contents":{"kind":"markdown","value":"` + "```go\\nfunc f(a int)\\n```" + `\n\nf does [something](http://a.b) \\_here\\_."}}`,
+		`{"contents":[{"language":"go","value":"func f(a int)"},"f does something _here_."]}`,
+		`{"contents":"` + "`f`" + ` **bold**"}`,
+	}
+	results := []string{
+		"func f(a int)\n\nf does something _here_.",
+		"func f(a int)\n\nf does something _here_.",
+		"f bold",
+	}
+	for i, msg := range msgs {
+		h := &Hover{}
+		if err := json.Unmarshal([]byte(msg), h); err != nil {
+			t.Fatal(err)
+		}
+		s := HoverToString(h)
+		if s != results[i] {
+			t.Fatalf("%v: %q", i, s)
+		}
+	}
+}
+
+func TestCodeActions1(t *testing.T) {
+	msg := `[
+		{"title":"cmd1","command":"gopls.cmd1","arguments":[{"a":1}]},
+		{"title":"action1","kind":"quickfix","isPreferred":true,"command":{"title":"c","command":"gopls.cmd2"}},
+		{"title":"action2","kind":"source.organizeImports","edit":{"changes":{"file:///a/b.go":[]}}}
+	]`
+	u := codeActionResponseUnion{}
+	if err := json.Unmarshal([]byte(msg), &u); err != nil {
+		t.Fatal(err)
+	}
+	if len(u.actions) != 3 {
+		t.Fatal(len(u.actions))
+	}
+	if c := u.actions[0].Command; c == nil || c.Command != "gopls.cmd1" || len(c.Arguments) != 1 {
+		t.Fatal(c)
+	}
+	if c := u.actions[1].Command; c == nil || c.Command != "gopls.cmd2" {
+		t.Fatal(c)
+	}
+	if u.actions[2].Edit == nil {
+		t.Fatal("expecting edit")
+	}
+
+	mcas := []*ManagerCodeAction{}
+	for i, ca := range u.actions {
+		mcas = append(mcas, &ManagerCodeAction{Id: i + 1, Filename: "/a/b.go", Action: ca})
+	}
+	s := CodeActionsToString(mcas)
+	s2 := "\tLsprotoCodeAction 1: cmd1\n" +
+		"\tLsprotoCodeAction 2: action1 (quickfix, preferred)\n" +
+		"\tLsprotoCodeAction 3: action2 (source.organizeImports)\n"
+	if s != s2 {
+		t.Fatalf("%q", s)
+	}
+}
+
+func TestSignatureHelp1(t *testing.T) {
+	msgs := []string{
+		`{"signatures":[{"label":"f(a int, b string)","documentation":"f does.","parameters":[{"label":"a int"},{"label":"b string"}]}],"activeParameter":1}`,
+		`{"signatures":[{"label":"g(ç int, b int)","parameters":[{"label":[2,7]},{"label":[9,14],"documentation":{"kind":"markdown","value":"**b** doc"}}],"activeParameter":1}]}`,
+		`{"signatures":[]}`,
+	}
+	results := []string{
+		"f(a int, «b string»)\n\nf does.",
+		"g(ç int, «b int»)\n\nb doc",
+		"",
+	}
+	for i, msg := range msgs {
+		sh := &SignatureHelp{}
+		if err := json.Unmarshal([]byte(msg), sh); err != nil {
+			t.Fatal(err)
+		}
+		s := SignatureHelpToString(sh)
+		if s != results[i] {
+			t.Fatalf("%v: %q", i, s)
+		}
+	}
+}
+
+func TestSymbols1(t *testing.T) {
+	msg := `[
+		{"name":"T","kind":23,"range":{"start":{"line":4,"character":0},"end":{"line":6,"character":1}},"selectionRange":{"start":{"line":4,"character":5},"end":{"line":4,"character":6}},
+			"children":[{"name":"a","kind":8,"range":{"start":{"line":5,"character":1},"end":{"line":5,"character":6}},"selectionRange":{"start":{"line":5,"character":1},"end":{"line":5,"character":2}}}]},
+		{"name":"main","kind":12,"location":{"uri":"file:///a/b.go","range":{"start":{"line":1,"character":5},"end":{"line":1,"character":9}}}}
+	]`
+	u := documentSymbolResponseUnion{}
+	if err := json.Unmarshal([]byte(msg), &u); err != nil {
+		t.Fatal(err)
+	}
+	s := DocumentSymbolsToString("/a/b.go", u.syms, "/a")
+	s2 := "\tb.go:2:6 function main\n" +
+		"\tb.go:5:6 struct T\n" +
+		"\t\tb.go:6:2 field a\n"
+	if s != s2 {
+		t.Fatalf("%q", s)
+	}
+
+	sis := []*SymbolInformation{
+		{Name: "m1", Kind: 6, ContainerName: "C", Location: &Location{Uri: "file:///a/c.cpp", Range: &Range{Start: Position{3, 1}}}},
+		{Name: "C", Kind: 5, Location: &Location{Uri: "file:///a/c.cpp", Range: &Range{Start: Position{1, 6}}}},
+		{Name: "f", Kind: 12, ContainerName: "pkg", Location: &Location{Uri: "file:///a/b.go"}},
+	}
+	s, err := WorkspaceSymbolsToString(sis, "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2 = "\tb.go:1:1 function f\n" +
+		"\tc.cpp:2:7 class C\n" +
+		"\t\tc.cpp:4:2 method m1\n"
+	if s != s2 {
+		t.Fatalf("%q", s)
+	}
+}
+
+func TestConfiguration1(t *testing.T) {
+	reg := &Registration{Config: `{"gopls":{"staticcheck":true,"env":{"A":"1"}}}`}
+	cli := &Client{li: &LangInstance{lang: &LangManager{Reg: reg}}}
+	items := []*ConfigurationItem{
+		{Section: "gopls"},
+		{Section: "gopls.env"},
+		{Section: "other"},
+	}
+	res, err := cli.configuration(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := `[{"env":{"A":"1"},"staticcheck":true},{"A":"1"},null]`
+	if string(b) != s {
+		t.Fatal(string(b))
+	}
+}
+
+func TestApplyTextEdits1(t *testing.T) {
+	rw := iorw.NewBytesReadWriterAt([]byte("a  :=  1\nb:=2\n"))
+	edits := []*TextEdit{
+		{Range: &Range{Start: Position{1, 1}, End: Position{1, 3}}, NewText: " := "},
+		{Range: &Range{Start: Position{0, 1}, End: Position{0, 7}}, NewText: " := "},
+	}
+	if err := ApplyTextEdits(rw, edits); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := iorw.ReadFastFull(rw)
+	if string(b) != "a := 1\nb := 2\n" {
+		t.Fatalf("%q", b)
+	}
+}
+
+func TestDocWritesChanges1(t *testing.T) {
+	text := []byte("ab\nçd\nef")
+	writes := []*docWrite{
+		{index: 6, n: 0, p: []byte("X")},    // "ab\nçdX\nef"
+		{index: 1, n: 4, p: []byte("12\n")}, // "a12\ndX\nef"
+	}
+	text2, changes, err := docWritesChanges(text, writes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text2) != "a12\ndX\nef" {
+		t.Fatalf("%q", text2)
+	}
+	r0, r1 := changes[0].Range, changes[1].Range
+	if r0.Start != (Position{1, 2}) || r0.End != (Position{1, 2}) {
+		t.Fatal(r0)
+	}
+	if r1.Start != (Position{0, 1}) || r1.End != (Position{1, 1}) {
+		t.Fatal(r1)
+	}
+
+	// out of range
+	writes = []*docWrite{{index: 20, n: 1}}
+	if _, _, err := docWritesChanges(text, writes); err == nil {
+		t.Fatal("expecting error")
+	}
+}
+
+func TestTypeHierarchy1(t *testing.T) {
+	msg := `[
+		{"name":"ReadWriter","kind":11,"uri":"file:///a/io.go","range":{"start":{"line":9,"character":0},"end":{"line":12,"character":1}},"selectionRange":{"start":{"line":9,"character":5},"end":{"line":9,"character":15}}},
+		{"name":"Reader","kind":11,"uri":"file:///a/b/r.go","range":{"start":{"line":2,"character":0},"end":{"line":4,"character":1}}}
+	]`
+	types := []*TypeHierarchyItem{}
+	if err := json.Unmarshal([]byte(msg), &types); err != nil {
+		t.Fatal(err)
+	}
+	item := &TypeHierarchyItem{Name: "File"}
+	mtypes := []*ManagerTypeHierarchyTypes{{item, types}}
+	s, err := ManagerTypeHierarchyTypesToString(mtypes, SupertypesTht, "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2 := "lsproto type hierarchy supertypes:\n" +
+		"supertypes of File: 2 results\n" +
+		"\tb/r.go:3:1: Reader\n" +
+		"\tio.go:10:6: ReadWriter"
+	if s != s2 {
+		t.Fatalf("%q", s)
+	}
+}
+
+func TestClientTap1(t *testing.T) {
+	man := NewManager(nil)
+	_ = man.Register(&Registration{Language: "go", Exts: []string{".go"}})
+	lang := man.langs[0]
+	li := &LangInstance{lang: lang}
+
+	frame := func(s string) string {
+		return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(s), s)
+	}
+	rwc := &testRwc{}
+	rwc.rd.WriteString(frame(`{"jsonrpc":"2.0","id":1,"result":{"a":1}}`))
+	tap := newClientTap(rwc, li)
+
+	// trace
+	w := &strings.Builder{}
+	ctx, cancel := context.WithCancel(context.Background())
+	traceDone := make(chan struct{})
+	go func() {
+		defer close(traceDone)
+		_ = man.Trace(ctx, "go", w)
+	}()
+	for !man.hasTraces(lang) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// request written in two parts
+	req := frame(`{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{"b":2}}`)
+	_, _ = tap.Write([]byte(req[:10]))
+	if n := len(tap.pendingRequests()); n != 0 {
+		t.Fatal(n)
+	}
+	_, _ = tap.Write([]byte(req[10:]))
+	if p := tap.pendingRequests(); len(p) != 1 || p[0].Method != "textDocument/hover" {
+		t.Fatal(p)
+	}
+	_, _ = tap.Write([]byte(frame(`{"jsonrpc":"2.0","method":"initialized","params":{}}`)))
+
+	// response
+	b := make([]byte, 1024)
+	if _, err := tap.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(tap.pendingRequests()); n != 0 {
+		t.Fatal(n)
+	}
+
+	cancel()
+	<-traceDone
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("%q", lines)
+	}
+	checks := []string{
+		`lsproto(go): --> req 1 textDocument/hover {"b":2}`,
+		`lsproto(go): --> notif initialized {}`,
+		`lsproto(go): <-- resp 1 textDocument/hover (`,
+	}
+	for i, c := range checks {
+		if !strings.Contains(lines[i], c) {
+			t.Fatalf("%q", lines[i])
+		}
+	}
+
+	if s := string(truncatePayload([]byte("abcdef"), 3)); s != "abc...3 more bytes" {
+		t.Fatal(s)
+	}
+}
+
+type testRwc struct {
+	rd, wr bytes.Buffer
+}
+
+func (rwc *testRwc) Read(p []byte) (int, error)  { return rwc.rd.Read(p) }
+func (rwc *testRwc) Write(p []byte) (int, error) { return rwc.wr.Write(p) }
+func (rwc *testRwc) Close() error                { return nil }
+
+func TestInlayHints1(t *testing.T) {
+	msg := `[
+		{"position":{"line":0,"character":7},"label":"a:","kind":2,"paddingRight":true},
+		{"position":{"line":1,"character":3},"label":[{"value":"[]"},{"value":"int"}],"kind":1,"paddingLeft":true}
+	]`
+	hints := []*InlayHint{}
+	if err := json.Unmarshal([]byte(msg), &hints); err != nil {
+		t.Fatal(err)
+	}
+	w := []string{}
+	for _, h := range hints {
+		w = append(w, InlayHintLabel(h))
+	}
+	if s := strings.Join(w, ","); s != "a: , []int" {
+		t.Fatalf("%q", s)
+	}
+}
+
+func TestSemanticTokens1(t *testing.T) {
+	text := []byte("func f(a int) {\n\tç := \"😀\"; _ = a\n}")
+	legend := &SemanticTokensLegend{
+		TokenTypes:     []string{"keyword", "function", "parameter", "type", "variable"},
+		TokenModifiers: []string{"declaration", "readonly"},
+	}
+	data := []int{
+		0, 0, 4, 0, 0, // func
+		0, 5, 1, 1, 1, // f
+		0, 2, 1, 2, 1, // a
+		0, 2, 3, 3, 0, // int
+		1, 1, 1, 4, 3, // ç
+		0, 15, 1, 2, 0, // a (after the surrogate pair)
+		0, 50, 1, 4, 0, // out of the line
+		5, 0, 1, 4, 0, // out of the text
+	}
+	toks := decodeSemanticTokens(text, data, legend)
+	w := []string{}
+	for _, tok := range toks {
+		s := fmt.Sprintf("%v:%q:%v:%v", tok.Offset, text[tok.Offset:tok.Offset+tok.Len], tok.Type, tok.Modifiers)
+		w = append(w, s)
+	}
+	s := strings.Join(w, ",")
+	s2 := `0:"func":keyword:[],5:"f":function:[declaration],7:"a":parameter:[declaration],9:"int":type:[],17:"ç":variable:[declaration readonly],35:"a":parameter:[]`
+	if s != s2 {
+		t.Fatal(s)
+	}
+
+	// delta edits
+	data2, err := applySemanticTokensEdits(data[:15], []*SemanticTokensEdit{
+		{Start: 10, DeleteCount: 5},
+		{Start: 0, DeleteCount: 0, Data: []int{0, 0, 1, 0, 0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(data2) != "[0 0 1 0 0 0 0 4 0 0 0 5 1 1 1]" {
+		t.Fatal(data2)
+	}
+}
+
+func TestSyncTextSecondaryServer1(t *testing.T) {
+	// primary server publishes diagnostics on open, the secondary (ex: a linter) only on save
+	srv1 := newTestLspServer(t, testLspDiagnosticsHandler("textDocument/didOpen", "err1"))
+	srv2 := newTestLspServer(t, testLspDiagnosticsHandler("textDocument/didSave", "lint1"))
+	man := newTestLspManager(t, srv1, srv2)
+
+	filename := filepath.Join(t.TempDir(), "a.tst")
+	rd := iorw.NewStringReaderAt("abc\n")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := man.SyncText(ctx, filename, rd); err != nil {
+		t.Fatal(err)
+	}
+
+	// merged diagnostics of both servers
+	for {
+		s := DiagnosticsToString(man.AllDiagnostics(), "")
+		if strings.Contains(s, "err1") && strings.Contains(s, "lint1") {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("missing diagnostics: %q", s)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestSemanticTokensDeltaError1(t *testing.T) {
+	// server that rejects the delta requests
+	nfull, ndelta := 0, 0
+	srv := newTestLspServer(t, func(conn *jsonrpc2.Connection, req *jsonrpc2.Request) (any, error) {
+		switch req.Method {
+		case "initialize":
+			caps := `{"capabilities":{"textDocumentSync":2,"semanticTokensProvider":{"legend":{"tokenTypes":["keyword"],"tokenModifiers":[]},"full":{"delta":true}}}}`
+			return json.RawMessage(caps), nil
+		case "textDocument/semanticTokens/full":
+			nfull++
+			return json.RawMessage(`{"resultId":"1","data":[0,0,3,0,0]}`), nil
+		case "textDocument/semanticTokens/full/delta":
+			ndelta++
+			return nil, fmt.Errorf("delta rejected")
+		}
+		return nil, nil
+	})
+	man := newTestLspManager(t, srv)
+
+	filename := filepath.Join(t.TempDir(), "a.tst")
+	rd := iorw.NewStringReaderAt("abc\n")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		toks, err := man.TextDocumentSemanticTokens(ctx, filename, rd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(toks) != 1 || toks[0].Type != "keyword" {
+			t.Fatal(toks)
+		}
+	}
+	// the delta failed once, and was retried with a full request
+	if nfull != 2 || ndelta != 1 {
+		t.Fatal(nfull, ndelta)
+	}
+}
+
+//----------
+
+func newTestLspManager(t *testing.T, srvs ...*testLspServer) *Manager {
+	t.Helper()
+	man := NewManager(nil)
+	t.Cleanup(man.Stop)
+	for i, srv := range srvs {
+		s := fmt.Sprintf("tst%d,.tst,tcpclient,%v", i, srv.ln.Addr())
+		reg, err := NewRegistration(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := man.Register(reg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return man
+}
+
+//----------
+
+type testLspServer struct {
+	ln     net.Listener
+	handle func(*jsonrpc2.Connection, *jsonrpc2.Request) (any, error)
+}
+
+func newTestLspServer(t *testing.T, handle func(*jsonrpc2.Connection, *jsonrpc2.Request) (any, error)) *testLspServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &testLspServer{ln: ln, handle: handle}
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := jsonrpc2.Serve(ctx, srv, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		_ = ln.Close()
+		_ = s.Wait()
+	})
+	return srv
+}
+
+// jsonrpc2.Listener
+func (srv *testLspServer) Accept(ctx context.Context) (io.ReadWriteCloser, error) {
+	return srv.ln.Accept()
+}
+func (srv *testLspServer) Close() error {
+	return srv.ln.Close()
+}
+func (srv *testLspServer) Dialer() jsonrpc2.Dialer {
+	return nil
+}
+
+// jsonrpc2.Binder
+func (srv *testLspServer) Bind(ctx context.Context, conn *jsonrpc2.Connection) (jsonrpc2.ConnectionOptions, error) {
+	handle := func(ctx context.Context, req *jsonrpc2.Request) (any, error) {
+		return srv.handle(conn, req)
+	}
+	return jsonrpc2.ConnectionOptions{Handler: jsonrpc2.HandlerFunc(handle)}, nil
+}
+
+// Publishes a diagnostic when it receives the given notification.
+func testLspDiagnosticsHandler(method, msg string) func(*jsonrpc2.Connection, *jsonrpc2.Request) (any, error) {
+	return func(conn *jsonrpc2.Connection, req *jsonrpc2.Request) (any, error) {
+		switch req.Method {
+		case "initialize":
+			caps := `{"capabilities":{"textDocumentSync":{"openClose":true,"change":2,"save":{}}}}`
+			return json.RawMessage(caps), nil
+		case method:
+			opt := struct {
+				TextDocument struct {
+					Uri DocumentUri `json:"uri"`
+				} `json:"textDocument"`
+			}{}
+			if err := json.Unmarshal(req.Params, &opt); err != nil {
+				return nil, err
+			}
+			pdp := &PublishDiagnosticsParams{Uri: opt.TextDocument.Uri}
+			pdp.Diagnostics = []*Diagnostic{{Range: &Range{}, Severity: 1, Message: msg}}
+			return nil, conn.Notify(context.Background(), "textDocument/publishDiagnostics", pdp)
+		}
+		return nil, nil
+	}
+}
+
 //----------
 //----------
 //----------
 
+func TestProgress1(t *testing.T) {
+	msgs := []string{}
+	man := NewManager(func(s string) { msgs = append(msgs, s) })
+	nev := 0
+	man.OnProgress = func(*ManagerProgress) { nev++ }
+
+	filename := "/a/b.go"
+	tok, done := man.workDoneProgress(nil, filename)
+	update := func(v string) {
+		t.Helper()
+		pp := &ProgressParams{Token: tok, Value: json.RawMessage(v)}
+		if err := man.updateProgress(pp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// not begun
+	if p := man.FileProgress(filename); p != nil {
+		t.Fatal(p)
+	}
+	update(`{"kind":"begin","title":"refs","message":"m1","cancellable":true}`)
+	update(`{"kind":"report","percentage":30}`)
+	p := man.FileProgress(filename)
+	if p == nil || p.Title != "refs" || p.Message != "m1" || p.Percentage != 30 || !p.Cancellable {
+		t.Fatalf("%+v", p)
+	}
+	update(`{"kind":"end","message":"m2"}`)
+	if p := man.FileProgress(filename); p != nil {
+		t.Fatal(p)
+	}
+	update(`{"kind":"report","percentage":50}`) // ignored, already ended
+	done()
+
+	// server initiated, not tied to a file
+	tok = ProgressToken("1")
+	man.createProgress(nil, tok)
+	update(`{"kind":"begin","title":"load"}`)
+	man.clearProgress()
+
+	if nev != 5 {
+		t.Fatal(nev)
+	}
+	s := strings.Join(msgs, "\n")
+	if s != "progress: refs: m1\nprogress: refs: done: m2\nprogress: load" {
+		t.Fatalf("%q", s)
+	}
+}
+
 func TestScripts(t *testing.T) {
 	log.SetFlags(0)
 	//log.SetPrefix("lsptester: ")
@@ -0,0 +1,178 @@
+package lsproto
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Client initiated tokens are tied to the file that issued the request, allowing the progress to be shown/cancelled on that file. The returned func must be called when the request is done.
+func (man *Manager) workDoneProgress(cli *Client, filename string) (ProgressToken, func()) {
+	man.progress.Lock()
+	man.progress.id++
+	tok := ProgressToken(strconv.Quote(fmt.Sprintf("editor-%d", man.progress.id)))
+	p := &ManagerProgress{Filename: filename, Percentage: -1, token: tok, cli: cli}
+	man.progress.m[string(tok)] = p
+	man.progress.Unlock()
+
+	// the server might not send the "end" value (ex: request cancelled)
+	done := func() { man.endProgress(tok) }
+	return tok, done
+}
+
+// Server initiated tokens (ex: initial workspace load) are not tied to any file.
+func (man *Manager) createProgress(cli *Client, tok ProgressToken) {
+	man.progress.Lock()
+	defer man.progress.Unlock()
+	p := &ManagerProgress{Percentage: -1, token: tok, cli: cli}
+	man.progress.m[string(tok)] = p
+}
+
+func (man *Manager) updateProgress(pp *ProgressParams) error {
+	v := &WorkDoneProgressValue{}
+	if err := decodeJsonRaw(pp.Value, v); err != nil {
+		return err
+	}
+
+	man.progress.Lock()
+	key := string(pp.Token)
+	p, ok := man.progress.m[key]
+	if !ok {
+		man.progress.Unlock()
+		return nil // unknown token (ex: request already done)
+	}
+	switch v.Kind {
+	case "begin":
+		p.begun = true
+		p.Title = v.Title
+		p.Message = v.Message
+	case "report":
+		// unset message keeps the previous one
+		if v.Message != "" {
+			p.Message = v.Message
+		}
+	case "end":
+		p.Message = v.Message
+		p.Done = true
+		delete(man.progress.m, key)
+	default:
+		man.progress.Unlock()
+		return nil // not a work done progress value (ex: partial result)
+	}
+	if v.Percentage != nil {
+		p.Percentage = *v.Percentage
+	}
+	if v.Cancellable != nil {
+		p.Cancellable = *v.Cancellable
+	}
+	p2 := *p
+	man.progress.Unlock()
+
+	man.onProgress(&p2, v.Kind)
+	return nil
+}
+
+func (man *Manager) endProgress(tok ProgressToken) {
+	man.progress.Lock()
+	key := string(tok)
+	p, ok := man.progress.m[key]
+	if ok {
+		delete(man.progress.m, key)
+		p.Done = true
+	}
+	man.progress.Unlock()
+
+	if ok && p.begun {
+		p.Message = ""
+		man.onProgress(p, "end")
+	}
+}
+
+func (man *Manager) clearProgress() {
+	man.progress.Lock()
+	ps := []*ManagerProgress{}
+	for _, p := range man.progress.m {
+		if p.begun {
+			p.Done = true
+			ps = append(ps, p)
+		}
+	}
+	man.progress.m = map[string]*ManagerProgress{}
+	man.progress.Unlock()
+
+	for _, p := range ps {
+		man.onProgress(p, "")
+	}
+}
+
+func (man *Manager) onProgress(p *ManagerProgress, kind string) {
+	// summary in the messages (reports are too verbose)
+	msg := ""
+	switch kind {
+	case "begin":
+		msg = fmt.Sprintf("progress: %v", p.Title)
+	case "end":
+		msg = fmt.Sprintf("progress: %v: done", p.Title)
+	}
+	if msg != "" {
+		if p.Message != "" {
+			msg += ": " + p.Message
+		}
+		if p.cli != nil {
+			msg = p.cli.li.lang.WrapMsg(msg)
+		}
+		man.Message(msg)
+	}
+
+	if man.OnProgress != nil {
+		man.OnProgress(p)
+	}
+}
+
+//----------
+
+// Returns the first (by token) ongoing progress of the file, nil if none.
+func (man *Manager) FileProgress(filename string) *ManagerProgress {
+	man.progress.Lock()
+	defer man.progress.Unlock()
+	ps := man.fileProgress(filename)
+	if len(ps) == 0 {
+		return nil
+	}
+	p := *ps[0]
+	return &p
+}
+
+// Sends a cancel for the cancellable ongoing progress of the file.
+func (man *Manager) CancelProgress(ctx context.Context, filename string) error {
+	man.progress.Lock()
+	ps := []*ManagerProgress{}
+	for _, p := range man.fileProgress(filename) {
+		if p.Cancellable {
+			ps = append(ps, p)
+		}
+	}
+	man.progress.Unlock()
+
+	for _, p := range ps {
+		if err := p.cli.WindowWorkDoneProgressCancel(ctx, p.token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Must have the lock.
+func (man *Manager) fileProgress(filename string) []*ManagerProgress {
+	ps := []*ManagerProgress{}
+	for _, p := range man.progress.m {
+		if p.begun && p.Filename == filename {
+			ps = append(ps, p)
+		}
+	}
+	sort.Slice(ps, func(a, b int) bool {
+		return string(ps[a].token) < string(ps[b].token)
+	})
+	return ps
+}
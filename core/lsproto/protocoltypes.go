@@ -172,16 +172,83 @@ func (u *_completionItemDocumentation) UnmarshalJSON(b []byte) error {
 	if err := json.Unmarshal(b, &u.mc); err == nil {
 		return nil
 	}
+	u.mc = nil // could have been allocated
 	return json.Unmarshal(b, &u.str)
 }
 
+func (u *_completionItemDocumentation) String() string {
+	if u.mc != nil {
+		if u.mc.Kind == "markdown" {
+			return MarkdownToPlainText(u.mc.Value)
+		}
+		return u.mc.Value
+	}
+	if u.str != nil {
+		return *u.str
+	}
+	return ""
+}
+
 //----------
 
 type MarkupContent struct {
 	Kind  MarkupKind `json:"kind"`
 	Value string     `json:"value"`
 }
-type MarkupKind string // ex: plaintext, markup
+type MarkupKind string // ex: plaintext, markdown
+
+//----------
+
+type Hover struct {
+	Contents hoverContents `json:"contents"`
+	Range    *Range        `json:"range,omitempty"`
+}
+
+// Can be a MarkupContent, a MarkedString, or a list of MarkedString.
+type hoverContents struct {
+	mc  *MarkupContent
+	mss []*MarkedString
+}
+
+func (u *hoverContents) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '[' {
+		return json.Unmarshal(b, &u.mss)
+	}
+	if len(b) > 0 && b[0] == '{' {
+		// markupcontent has a "kind", markedstring has a "language"
+		var m map[string]any
+		if err := json.Unmarshal(b, &m); err != nil {
+			return err
+		}
+		if _, ok := m["kind"]; ok {
+			return json.Unmarshal(b, &u.mc)
+		}
+	}
+	ms := &MarkedString{}
+	if err := json.Unmarshal(b, ms); err != nil {
+		return err
+	}
+	u.mss = []*MarkedString{ms}
+	return nil
+}
+
+// Deprecated in the protocol in favor of MarkupContent.
+type MarkedString struct {
+	Language string `json:"language"`
+	Value    string `json:"value"`
+}
+
+func (ms *MarkedString) UnmarshalJSON(b []byte) error {
+	// plain string is markdown
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		*ms = MarkedString{Value: str}
+		return nil
+	}
+	type ms2 MarkedString // avoid recursion
+	return json.Unmarshal(b, (*ms2)(ms))
+}
 
 //----------
 
@@ -210,9 +277,9 @@ type VersionedTextDocumentIdentifier struct {
 	Version *int `json:"version"`
 }
 type TextDocumentContentChangeEvent struct {
-	Range       Range  `json:"range,omitempty"`
+	Range       *Range `json:"range,omitempty"` // nil: full content
 	RangeLength int    `json:"rangeLength,omitempty"`
-	Text        string `json:"text,omitempty"`
+	Text        string `json:"text"`
 }
 
 type DidChangeWorkspaceFoldersParams struct {
@@ -278,6 +345,7 @@ type CallHierarchyPrepareParams struct {
 }
 
 type CallHierarchyIncomingCallsParams struct {
+	WorkDoneProgressParams
 	Item *CallHierarchyItem `json:"item"`
 }
 type CallHierarchyIncomingCall struct {
@@ -286,6 +354,7 @@ type CallHierarchyIncomingCall struct {
 }
 
 type CallHierarchyOutgoingCallsParams struct {
+	WorkDoneProgressParams
 	Item *CallHierarchyItem `json:"item"`
 }
 type CallHierarchyOutgoingCall struct {
@@ -295,6 +364,30 @@ type CallHierarchyOutgoingCall struct {
 
 //----------
 
+type TypeHierarchyPrepareParams struct {
+	TextDocumentPositionParams
+}
+type TypeHierarchyItem struct {
+	Name           string       `json:"name"`
+	Kind           SymbolKind   `json:"kind"`
+	Tags           []*SymbolTag `json:"tags,omitempty"`   // optional
+	Detail         string       `json:"detail,omitempty"` // optional
+	Uri            DocumentUri  `json:"uri"`
+	Range          *Range       `json:"range"`
+	SelectionRange *Range       `json:"selectionRange"`
+	Data           any          `json:"data,omitempty"` // optional (related to prepare calls)
+}
+type TypeHierarchySupertypesParams struct {
+	WorkDoneProgressParams
+	Item *TypeHierarchyItem `json:"item"`
+}
+type TypeHierarchySubtypesParams struct {
+	WorkDoneProgressParams
+	Item *TypeHierarchyItem `json:"item"`
+}
+
+//----------
+
 // Not part of the protocol, used to unify/simplify
 type CallHierarchyCall struct {
 	Item       *CallHierarchyItem
@@ -316,12 +409,331 @@ type CallHierarchyItem struct {
 
 type ReferenceParams struct {
 	TextDocumentPositionParams
+	WorkDoneProgressParams
 	Context ReferenceContext `json:"context"`
 }
 type ReferenceContext struct {
 	IncludeDeclaration bool `json:"includeDeclaration"`
 }
 
+type CodeActionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Context      CodeActionContext      `json:"context"`
+}
+type CodeActionContext struct {
+	Diagnostics []*Diagnostic `json:"diagnostics"`
+	Only        []string      `json:"only,omitempty"`
+}
+type CodeAction struct {
+	Title       string          `json:"title"`
+	Kind        string          `json:"kind,omitempty"` // ex: quickfix, refactor.extract, source.organizeImports
+	Diagnostics []*Diagnostic   `json:"diagnostics,omitempty"`
+	IsPreferred bool            `json:"isPreferred,omitempty"`
+	Edit        *WorkspaceEdit  `json:"edit,omitempty"`
+	Command     *Command        `json:"command,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+}
+type Command struct {
+	Title     string            `json:"title"`
+	Command   string            `json:"command"`
+	Arguments []json.RawMessage `json:"arguments,omitempty"`
+}
+type ExecuteCommandParams struct {
+	Command   string            `json:"command"`
+	Arguments []json.RawMessage `json:"arguments,omitempty"`
+}
+
+// Items can be a Command or a CodeAction.
+type codeActionResponseUnion struct {
+	actions []*CodeAction
+}
+
+func (r *codeActionResponseUnion) UnmarshalJSON(data []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return err
+	}
+	r.actions = nil
+	for _, raw := range raws {
+		// a command has a string "command" field
+		var u struct {
+			Command any `json:"command"`
+		}
+		if err := json.Unmarshal(raw, &u); err != nil {
+			return err
+		}
+		if _, ok := u.Command.(string); ok {
+			cmd := &Command{}
+			if err := json.Unmarshal(raw, cmd); err != nil {
+				return err
+			}
+			ca := &CodeAction{Title: cmd.Title, Command: cmd}
+			r.actions = append(r.actions, ca)
+			continue
+		}
+		ca := &CodeAction{}
+		if err := json.Unmarshal(raw, ca); err != nil {
+			return err
+		}
+		r.actions = append(r.actions, ca)
+	}
+	return nil
+}
+
+//----------
+
+type ApplyWorkspaceEditParams struct {
+	Label string        `json:"label,omitempty"`
+	Edit  WorkspaceEdit `json:"edit"`
+}
+type ApplyWorkspaceEditResult struct {
+	Applied       bool   `json:"applied"`
+	FailureReason string `json:"failureReason,omitempty"`
+}
+
+//----------
+
+type SignatureHelpParams struct {
+	TextDocumentPositionParams
+	Context *SignatureHelpContext `json:"context,omitempty"`
+}
+type SignatureHelpContext struct {
+	TriggerKind      int    `json:"triggerKind"` // 1=invoked, 2=char, 3=content change
+	TriggerCharacter string `json:"triggerCharacter,omitempty"`
+	IsRetrigger      bool   `json:"isRetrigger"`
+}
+type SignatureHelp struct {
+	Signatures      []*SignatureInformation `json:"signatures"`
+	ActiveSignature int                     `json:"activeSignature,omitempty"`
+	ActiveParameter int                     `json:"activeParameter,omitempty"`
+}
+type SignatureInformation struct {
+	Label           string                       `json:"label"`
+	Documentation   _completionItemDocumentation `json:"documentation,omitempty"`
+	Parameters      []*ParameterInformation      `json:"parameters,omitempty"`
+	ActiveParameter *int                         `json:"activeParameter,omitempty"`
+}
+type ParameterInformation struct {
+	Label         json.RawMessage              `json:"label"` // string or [start,end] utf16 offsets in the signature label
+	Documentation _completionItemDocumentation `json:"documentation,omitempty"`
+}
+
+//----------
+
+type InlayHintParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+type InlayHint struct {
+	Position     Position        `json:"position"`
+	Label        json.RawMessage `json:"label"` // string or []*InlayHintLabelPart
+	Kind         InlayHintKind   `json:"kind,omitempty"`
+	PaddingLeft  bool            `json:"paddingLeft,omitempty"`
+	PaddingRight bool            `json:"paddingRight,omitempty"`
+}
+type InlayHintLabelPart struct {
+	Value string `json:"value"`
+}
+type InlayHintKind int
+
+const (
+	InlayHintKindType InlayHintKind = iota + 1
+	InlayHintKindParameter
+)
+
+//----------
+
+type SemanticTokensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+type SemanticTokensDeltaParams struct {
+	TextDocument     TextDocumentIdentifier `json:"textDocument"`
+	PreviousResultId string                 `json:"previousResultId"`
+}
+type SemanticTokensLegend struct {
+	TokenTypes     []string `json:"tokenTypes"`
+	TokenModifiers []string `json:"tokenModifiers"`
+}
+type SemanticTokens struct {
+	ResultId string `json:"resultId,omitempty"`
+	Data     []int  `json:"data"` // groups of 5: deltaLine, deltaStartChar, length, tokenType, tokenModifiers
+}
+
+// Response to a delta request, can have the full data or the edits to the previous data.
+type SemanticTokensDelta struct {
+	ResultId string                `json:"resultId,omitempty"`
+	Data     []int                 `json:"data,omitempty"`
+	Edits    []*SemanticTokensEdit `json:"edits,omitempty"`
+}
+type SemanticTokensEdit struct {
+	Start       int   `json:"start"`
+	DeleteCount int   `json:"deleteCount"`
+	Data        []int `json:"data,omitempty"`
+}
+
+//----------
+
+type DocumentSymbolParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+type WorkspaceSymbolParams struct {
+	WorkDoneProgressParams
+	Query string `json:"query"`
+}
+type DocumentSymbol struct {
+	Name           string            `json:"name"`
+	Detail         string            `json:"detail,omitempty"`
+	Kind           SymbolKind        `json:"kind"`
+	Range          *Range            `json:"range"`
+	SelectionRange *Range            `json:"selectionRange"`
+	Children       []*DocumentSymbol `json:"children,omitempty"`
+}
+type SymbolInformation struct {
+	Name          string     `json:"name"`
+	Kind          SymbolKind `json:"kind"`
+	Location      *Location  `json:"location"`
+	ContainerName string     `json:"containerName,omitempty"`
+}
+
+func (sk SymbolKind) String() string {
+	names := []string{"file", "module", "namespace", "package", "class", "method", "property", "field", "constructor", "enum", "interface", "function", "variable", "constant", "string", "number", "boolean", "array", "object", "key", "null", "enummember", "struct", "event", "operator", "typeparameter"}
+	i := int(sk) - 1 // one based
+	if i >= 0 && i < len(names) {
+		return names[i]
+	}
+	return "symbol"
+}
+
+// Items can be a DocumentSymbol or a SymbolInformation. Returns always DocumentSymbols.
+type documentSymbolResponseUnion struct {
+	syms []*DocumentSymbol
+}
+
+func (r *documentSymbolResponseUnion) UnmarshalJSON(data []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return err
+	}
+	r.syms = nil
+	for _, raw := range raws {
+		// symbolinformation has a location
+		var u struct {
+			Location *Location `json:"location"`
+		}
+		if err := json.Unmarshal(raw, &u); err != nil {
+			return err
+		}
+		if u.Location != nil {
+			si := &SymbolInformation{}
+			if err := json.Unmarshal(raw, si); err != nil {
+				return err
+			}
+			rang := si.Location.Range
+			if rang == nil {
+				rang = &Range{}
+			}
+			ds := &DocumentSymbol{Name: si.Name, Kind: si.Kind, Range: rang, SelectionRange: rang}
+			r.syms = append(r.syms, ds)
+			continue
+		}
+		ds := &DocumentSymbol{}
+		if err := json.Unmarshal(raw, ds); err != nil {
+			return err
+		}
+		r.syms = append(r.syms, ds)
+	}
+	return nil
+}
+
+//----------
+
+type ConfigurationParams struct {
+	Items []*ConfigurationItem `json:"items"`
+}
+type ConfigurationItem struct {
+	ScopeUri DocumentUri `json:"scopeUri,omitempty"`
+	Section  string      `json:"section,omitempty"`
+}
+type DidChangeConfigurationParams struct {
+	Settings any `json:"settings"`
+}
+
+//----------
+
+type ShowMessageParams struct {
+	Type    MessageType `json:"type"`
+	Message string      `json:"message"`
+}
+type LogMessageParams ShowMessageParams
+
+type MessageType int
+
+const (
+	MessageTypeError   MessageType = 1
+	MessageTypeWarning MessageType = 2
+	MessageTypeInfo    MessageType = 3
+	MessageTypeLog     MessageType = 4
+)
+
+func (mt MessageType) String() string {
+	switch mt {
+	case MessageTypeError:
+		return "error"
+	case MessageTypeWarning:
+		return "warning"
+	case MessageTypeInfo:
+		return "info"
+	case MessageTypeLog:
+		return "log"
+	}
+	return "message"
+}
+
+//----------
+
+// integer or string
+type ProgressToken = json.RawMessage
+
+type WorkDoneProgressParams struct {
+	WorkDoneToken ProgressToken `json:"workDoneToken,omitempty"`
+}
+type WorkDoneProgressCreateParams struct {
+	Token ProgressToken `json:"token"`
+}
+type WorkDoneProgressCancelParams struct {
+	Token ProgressToken `json:"token"`
+}
+type ProgressParams struct {
+	Token ProgressToken   `json:"token"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Unifies the begin/report/end values.
+type WorkDoneProgressValue struct {
+	Kind        string `json:"kind"` // "begin", "report", "end"
+	Title       string `json:"title,omitempty"`
+	Message     string `json:"message,omitempty"`
+	Percentage  *int   `json:"percentage,omitempty"`
+	Cancellable *bool  `json:"cancellable,omitempty"`
+}
+
+//----------
+
+type DocumentFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Options      FormattingOptions      `json:"options"`
+}
+type DocumentRangeFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Options      FormattingOptions      `json:"options"`
+}
+type FormattingOptions struct {
+	TabSize      int  `json:"tabSize"`
+	InsertSpaces bool `json:"insertSpaces"`
+}
+
 type Position struct {
 	Line      int `json:"line"`      // zero based
 	Character int `json:"character"` // zero based
@@ -335,6 +747,65 @@ type DocumentUri string
 type SymbolKind int
 type SymbolTag int
 
+type TextDocumentSyncKind int
+
+const (
+	TextDocumentSyncKindNone TextDocumentSyncKind = iota
+	TextDocumentSyncKindFull
+	TextDocumentSyncKindIncremental
+)
+
+func (k TextDocumentSyncKind) String() string {
+	switch k {
+	case TextDocumentSyncKindNone:
+		return "none"
+	case TextDocumentSyncKindFull:
+		return "full"
+	case TextDocumentSyncKindIncremental:
+		return "incremental"
+	}
+	return fmt.Sprintf("%d", int(k))
+}
+
+//----------
+
+type PublishDiagnosticsParams struct {
+	Uri         DocumentUri   `json:"uri"`
+	Version     *int          `json:"version,omitempty"`
+	Diagnostics []*Diagnostic `json:"diagnostics"`
+}
+type Diagnostic struct {
+	Range    *Range             `json:"range"`
+	Severity DiagnosticSeverity `json:"severity,omitempty"`
+	Code     any                `json:"code,omitempty"`
+	Source   string             `json:"source,omitempty"`
+	Message  string             `json:"message"`
+}
+
+type DiagnosticSeverity int
+
+const (
+	DiagnosticSeverityError DiagnosticSeverity = iota + 1
+	DiagnosticSeverityWarning
+	DiagnosticSeverityInformation
+	DiagnosticSeverityHint
+)
+
+func (ds DiagnosticSeverity) String() string {
+	switch ds {
+	case DiagnosticSeverityError:
+		return "error"
+	case DiagnosticSeverityWarning:
+		return "warning"
+	case DiagnosticSeverityInformation:
+		return "info"
+	case DiagnosticSeverityHint:
+		return "hint"
+	default:
+		return "diagnostic"
+	}
+}
+
 //----------
 //----------
 //----------
@@ -349,8 +820,81 @@ const (
 
 //----------
 
+// Not part of the protocol, used to unify/simplify
+type TypeHierarchyType int
+
+const (
+	SupertypesTht TypeHierarchyType = iota
+	SubtypesTht
+)
+
+//----------
+
 // Not part of the protocol, used to unify/simplify
 type WorkspaceEditChange struct {
 	Filename string
 	Edits    []*TextEdit
 }
+
+//----------
+
+// Not part of the protocol, used to unify/simplify
+type FileDiagnostics struct {
+	Filename    string
+	Diagnostics []*Diagnostic
+}
+
+//----------
+
+// Not part of the protocol, used to unify/simplify
+type ManagerCodeAction struct {
+	Id       int // used to select the action to apply
+	Filename string
+	Action   *CodeAction
+
+	cli *Client // server that listed the action
+}
+
+//----------
+
+// Not part of the protocol, used to unify/simplify
+type SemanticToken struct {
+	Offset    int // byte offset
+	Len       int // byte length
+	Type      string
+	Modifiers []string
+}
+
+func (st *SemanticToken) HasModifier(m string) bool {
+	for _, m2 := range st.Modifiers {
+		if m2 == m {
+			return true
+		}
+	}
+	return false
+}
+
+//----------
+
+// Not part of the protocol, used to unify/simplify
+type ManagerInlayHint struct {
+	Offset int    // byte offset
+	Label  string // includes padding
+	Kind   InlayHintKind
+}
+
+//----------
+
+// Not part of the protocol, used to unify/simplify
+type ManagerProgress struct {
+	Filename    string // file that issued the request, empty if created by the server
+	Title       string
+	Message     string
+	Percentage  int // -1 if unknown
+	Cancellable bool
+	Done        bool
+
+	token ProgressToken
+	cli   *Client
+	begun bool
+}
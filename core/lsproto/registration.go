@@ -1,6 +1,7 @@
 package lsproto
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -13,7 +14,8 @@ type Registration struct {
 	Exts     []string
 	Network  string   // {stdio,tcpclient,tcp}
 	Cmd      string   // template values: {.Addr,.Host,.Port}
-	Optional []string // {stderr,nogotoimpl}
+	Optional []string // {stderr,nogotoimpl,format,sharedroots,root:<marker>}
+	Config   string   // optional json, answers "workspace/configuration" requests (ex: {"gopls":{"staticcheck":true}})
 }
 
 func NewRegistration(s string) (*Registration, error) {
@@ -44,6 +46,29 @@ func (reg *Registration) HasOptional(s string) bool {
 	return false
 }
 
+// Files that mark a project root (ex: go.mod), in order of preference. Given with the optional "root:<marker>" values, otherwise defaults for some languages are used.
+func (reg *Registration) RootMarkers() []string {
+	u := []string{}
+	for _, v := range reg.Optional {
+		if m, ok := strings.CutPrefix(v, "root:"); ok && m != "" {
+			u = append(u, m)
+		}
+	}
+	if len(u) > 0 {
+		return u
+	}
+
+	switch strings.ToLower(reg.Language) {
+	case "go":
+		return []string{"go.work", "go.mod"}
+	case "c", "cpp", "c/c++":
+		return []string{"compile_commands.json", "compile_flags.txt"}
+	case "python":
+		return []string{"pyproject.toml", "setup.py"}
+	}
+	return nil
+}
+
 func (reg *Registration) String() string {
 	return stringifyRegistration(reg)
 }
@@ -71,8 +96,16 @@ func parseRegistration(s string) (*Registration, error) {
 	reg.Exts = strings.Split(fields[1], " ")
 	reg.Network = fields[2]
 	reg.Cmd = fields[3]
-	if len(fields) > 4 {
-		reg.Optional = strings.Split(fields[4], " ")
+	// remaining fields: optional values and config json (starts with "{")
+	for _, f := range fields[4:] {
+		if strings.HasPrefix(f, "{") {
+			if !json.Valid([]byte(f)) {
+				return nil, fmt.Errorf("invalid config json: %v", f)
+			}
+			reg.Config = f
+			continue
+		}
+		reg.Optional = append(reg.Optional, strings.Split(f, " ")...)
 	}
 
 	return reg, nil
@@ -105,6 +138,9 @@ func stringifyRegistration(reg *Registration) string {
 		}
 		u = append(u, h)
 	}
+	if reg.Config != "" {
+		u = append(u, fmt.Sprintf("%q", reg.Config))
+	}
 	return strings.Join(u, ",")
 }
 
@@ -118,6 +154,9 @@ func RegistrationExamples() []string {
 		"python,.py,stdio,pylsp",
 		"python,.py,tcpclient,127.0.0.1:9000",
 		"python,.py,stdio,pylsp,\"stderr nogotoimpl\"",
+		"go,.go,stdio,gopls,format,'{\"gopls\":{\"staticcheck\":true}}'",
+		"go,.go,stdio,gopls,\"sharedroots root:go.mod\"",
+		"golint,.go,stdio,golangci-lint-langserver",
 	}
 }
 
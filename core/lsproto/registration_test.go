@@ -1,6 +1,9 @@
 package lsproto
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestParseRegistration1(t *testing.T) {
 	s := "go,.go,tcp,goexec"
@@ -51,4 +54,58 @@ func TestParseRegistration4(t *testing.T) {
 	}
 }
 
+func TestParseRegistration5(t *testing.T) {
+	s := `go,.go,stdio,gopls,format,'{"gopls":{"a":true, "b":1}}'`
+	reg, err := NewRegistration(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reg.Config != `{"gopls":{"a":true, "b":1}}` {
+		t.Fatal(reg.Config)
+	}
+	s2 := reg.String()
+	if s2 != `go,.go,stdio,gopls,format,"{\"gopls\":{\"a\":true, \"b\":1}}"` {
+		t.Fatal(s2)
+	}
+	// parse again
+	reg2, err := NewRegistration(s2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reg2.Config != reg.Config {
+		t.Fatal(reg2.Config)
+	}
+
+	// invalid json
+	if _, err := NewRegistration(`go,.go,stdio,gopls,'{"gopls"'`); err == nil {
+		t.Fatal("expecting error")
+	}
+}
+
 //----------
+
+func TestParseRegistrationRoots1(t *testing.T) {
+	s := "go,.go,stdio,gopls,\"sharedroots root:go.mod root:go.work\""
+	reg, err := NewRegistration(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reg.HasOptional("sharedroots") {
+		t.Fatal("expecting sharedroots")
+	}
+	if s2 := strings.Join(reg.RootMarkers(), " "); s2 != "go.mod go.work" {
+		t.Fatal(s2)
+	}
+	if s2 := reg.String(); s2 != s {
+		t.Fatal(s2)
+	}
+
+	// defaults
+	reg2, err := NewRegistration("go,.go,stdio,gopls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2 := strings.Join(reg2.RootMarkers(), " "); s2 != "go.work go.mod" {
+		t.Fatal(s2)
+	}
+}
@@ -0,0 +1,176 @@
+package lsproto
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// Token types/modifiers announced to the server (the server legend is used to decode).
+var semanticTokenTypes = []string{
+	"namespace", "type", "class", "enum", "interface", "struct", "typeParameter", "parameter", "variable", "property", "enumMember", "event", "function", "method", "macro", "keyword", "modifier", "comment", "string", "number", "regexp", "operator", "decorator",
+}
+var semanticTokenModifiers = []string{
+	"declaration", "definition", "readonly", "static", "deprecated", "abstract", "async", "modification", "documentation", "defaultLibrary",
+}
+
+//----------
+
+// Requests the semantic tokens of an open document. Uses a delta request if a previous result is known. Returns the document text the tokens refer to.
+func (cli *Client) semanticTokens(ctx context.Context, filename string) ([]byte, []int, error) {
+	cli.lock.Lock()
+	doc, ok := cli.lock.docs[filename]
+	if !ok {
+		cli.lock.Unlock()
+		return nil, nil, fmt.Errorf("document not open: %v", filename)
+	}
+	text := doc.text
+	prev := doc.semTokens
+	cli.lock.Unlock()
+
+	res := (*SemanticTokens)(nil)
+	if prev != nil && prev.ResultId != "" && cli.serverCapabilities.semanticTokens.delta {
+		r, err := cli.semanticTokensDelta(ctx, filename, prev)
+		if err == nil {
+			res = r
+		} else {
+			// clear the previous result (the next requests would send the same failing delta), retry with a full request
+			cli.setSemanticTokens(filename, doc, nil)
+		}
+	}
+	if res == nil {
+		res = &SemanticTokens{}
+		st, err := cli.TextDocumentSemanticTokensFull(ctx, filename)
+		if err != nil {
+			return nil, nil, err
+		}
+		if st != nil {
+			res = st
+		}
+	}
+
+	// keep result for the next delta request
+	cli.setSemanticTokens(filename, doc, res)
+
+	return text, res.Data, nil
+}
+
+func (cli *Client) semanticTokensDelta(ctx context.Context, filename string, prev *SemanticTokens) (*SemanticTokens, error) {
+	d, err := cli.TextDocumentSemanticTokensFullDelta(ctx, filename, prev.ResultId)
+	if err != nil {
+		return nil, err
+	}
+	res := &SemanticTokens{}
+	if d != nil {
+		res.ResultId = d.ResultId
+		if d.Data != nil {
+			res.Data = d.Data
+		} else {
+			data, err := applySemanticTokensEdits(prev.Data, d.Edits)
+			if err != nil {
+				return nil, err
+			}
+			res.Data = data
+		}
+	}
+	return res, nil
+}
+
+func (cli *Client) setSemanticTokens(filename string, doc *clientDoc, st *SemanticTokens) {
+	cli.lock.Lock()
+	defer cli.lock.Unlock()
+	if cli.lock.docs[filename] == doc {
+		doc.semTokens = st
+	}
+}
+
+//----------
+
+func applySemanticTokensEdits(data []int, edits []*SemanticTokensEdit) ([]int, error) {
+	// apply from the end to keep the start indexes valid
+	edits2 := append([]*SemanticTokensEdit{}, edits...)
+	sort.SliceStable(edits2, func(a, b int) bool {
+		return edits2[a].Start > edits2[b].Start
+	})
+	u := append([]int{}, data...)
+	for _, e := range edits2 {
+		if e.Start < 0 || e.DeleteCount < 0 || e.Start+e.DeleteCount > len(u) {
+			return nil, fmt.Errorf("bad semantic tokens edit: start=%v, deleteCount=%v, len=%v", e.Start, e.DeleteCount, len(u))
+		}
+		w := make([]int, 0, len(u)-e.DeleteCount+len(e.Data))
+		w = append(w, u[:e.Start]...)
+		w = append(w, e.Data...)
+		w = append(w, u[e.Start+e.DeleteCount:]...)
+		u = w
+	}
+	return u, nil
+}
+
+//----------
+
+// Decodes the relative encoded tokens into byte offsets of the text. Tokens that don't fit the text are discarded.
+func decodeSemanticTokens(text []byte, data []int, legend *SemanticTokensLegend) []*SemanticToken {
+	toks := []*SemanticToken{}
+	lineStart := 0
+	bi, col := 0, 0 // byte index and utf16 column of the previous token start
+	for i := 0; i+5 <= len(data); i += 5 {
+		dLine, dChar, n, typ, mods := data[i], data[i+1], data[i+2], data[i+3], data[i+4]
+
+		target := col + dChar
+		if dLine > 0 {
+			for k := 0; k < dLine; k++ {
+				j := bytes.IndexByte(text[lineStart:], '\n')
+				if j < 0 {
+					return toks
+				}
+				lineStart += j + 1
+			}
+			bi, col = lineStart, 0
+			target = dChar
+		}
+
+		start, ok := advanceUtf16(text, bi, col, target)
+		if !ok {
+			bi, col = -1, target // next tokens in this line are also discarded
+			continue
+		}
+		bi, col = start, target
+		end, ok := advanceUtf16(text, start, target, target+n)
+		if !ok {
+			continue
+		}
+
+		tok := &SemanticToken{Offset: start, Len: end - start}
+		if typ >= 0 && typ < len(legend.TokenTypes) {
+			tok.Type = legend.TokenTypes[typ]
+		}
+		for k, m := range legend.TokenModifiers {
+			if mods&(1<<k) != 0 {
+				tok.Modifiers = append(tok.Modifiers, m)
+			}
+		}
+		toks = append(toks, tok)
+	}
+	return toks
+}
+
+// Returns the byte index of the utf16 column target, starting at byte index i (utf16 column col) in the same line.
+func advanceUtf16(text []byte, i, col, target int) (int, bool) {
+	if i < 0 {
+		return 0, false
+	}
+	for col < target {
+		if i >= len(text) || text[i] == '\n' {
+			return 0, false
+		}
+		r, size := utf8.DecodeRune(text[i:])
+		i += size
+		col++
+		if r >= 0x10000 {
+			col++ // surrogate pair
+		}
+	}
+	return i, col == target
+}
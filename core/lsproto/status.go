@@ -0,0 +1,87 @@
+package lsproto
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Summary of the registrations and running instances: negotiated capabilities, workspace folders, open documents and pending requests.
+func (man *Manager) Status() string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "lsproto status: %d instances\n", man.NInstances())
+	for _, lang := range man.langs {
+		fmt.Fprintf(buf, "%v\n", lang.Reg)
+		lis := lang.instances()
+		if len(lis) == 0 {
+			fmt.Fprintf(buf, "\tnot running\n")
+			continue
+		}
+		for _, li := range lis {
+			li.status(buf)
+		}
+	}
+	return buf.String()
+}
+
+func (li *LangInstance) status(buf *bytes.Buffer) {
+	root := li.root
+	if root == "" {
+		root = "(none)"
+	}
+	fmt.Fprintf(buf, "\troot: %v\n", root)
+
+	cli := li.cli
+	fmt.Fprintf(buf, "\t\tcapabilities: %v\n", cli.serverCapabilitiesString())
+
+	cli.lock.Lock()
+	folders := []string{}
+	for _, f := range cli.lock.folders {
+		folders = append(folders, string(f.Uri))
+	}
+	docs := []string{}
+	for filename, doc := range cli.lock.docs {
+		docs = append(docs, fmt.Sprintf("%v (version %v)", filename, doc.version))
+	}
+	cli.lock.Unlock()
+	sort.Strings(docs)
+
+	fmt.Fprintf(buf, "\t\tworkspace folders: %v\n", strings.Join(folders, " "))
+	fmt.Fprintf(buf, "\t\topen documents: %d\n", len(docs))
+	for _, s := range docs {
+		fmt.Fprintf(buf, "\t\t\t%v\n", s)
+	}
+
+	pending := cli.tap.pendingRequests()
+	fmt.Fprintf(buf, "\t\tpending requests: %d\n", len(pending))
+	for _, p := range pending {
+		d := time.Since(p.Start).Round(time.Millisecond)
+		fmt.Fprintf(buf, "\t\t\t%v %v (%v)\n", p.Id, p.Method, d)
+	}
+}
+
+//----------
+
+func (cli *Client) serverCapabilitiesString() string {
+	cli.lock.Lock()
+	defer cli.lock.Unlock()
+	sc := &cli.serverCapabilities
+	u := []string{}
+	add := func(name string, v bool) {
+		if v {
+			u = append(u, name)
+		}
+	}
+	add("workspaceFolders", sc.workspace.folders)
+	add("workspaceFoldersChange", sc.workspace.foldersChange)
+	add("workspaceSymbol", sc.workspace.symbol)
+	add("rename", sc.rename)
+	add("codeActionResolve", sc.codeActionResolve)
+	add("inlayHint", sc.inlayHint)
+	add("semanticTokens", sc.semanticTokens.full)
+	add("semanticTokensDelta", sc.semanticTokens.delta)
+	u = append(u, fmt.Sprintf("textDocumentSync=%v", sc.textDocumentSync))
+	return strings.Join(u, " ")
+}
@@ -0,0 +1,275 @@
+package lsproto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// max bytes of the message payload shown in a trace line
+var traceMaxPayload = 200
+
+//----------
+
+// Tap on the client connection: keeps track of the pending requests and sends the messages to the manager traces.
+type clientTap struct {
+	rwc io.ReadWriteCloser
+	li  *LangInstance
+
+	in, out tapFramer // only read/written by one goroutine each
+
+	pending struct {
+		sync.Mutex
+		m map[string]*tapPending // key is the request id
+	}
+}
+
+type tapPending struct {
+	Id     string
+	Method string
+	Start  time.Time
+}
+
+func newClientTap(rwc io.ReadWriteCloser, li *LangInstance) *clientTap {
+	tap := &clientTap{rwc: rwc, li: li}
+	tap.pending.m = map[string]*tapPending{}
+	return tap
+}
+
+func (tap *clientTap) Read(p []byte) (int, error) {
+	n, err := tap.rwc.Read(p)
+	if n > 0 {
+		tap.in.write(p[:n], func(b []byte) { tap.onMessage(b, false) })
+	}
+	return n, err
+}
+func (tap *clientTap) Write(p []byte) (int, error) {
+	n, err := tap.rwc.Write(p)
+	if n > 0 {
+		tap.out.write(p[:n], func(b []byte) { tap.onMessage(b, true) })
+	}
+	return n, err
+}
+func (tap *clientTap) Close() error {
+	return tap.rwc.Close()
+}
+
+//----------
+
+func (tap *clientTap) onMessage(b []byte, out bool) {
+	// only the id and method to track the pending requests (payloads can be big, ex: didOpen text)
+	head := &tapMessageHead{}
+	if err := json.Unmarshal(b, head); err != nil {
+		return // not json
+	}
+	id := string(head.Id)
+	if id == "null" {
+		id = ""
+	}
+
+	// pending requests issued by the client
+	latency := time.Duration(-1)
+	method := head.Method
+	switch {
+	case out && head.Method != "" && id != "": // request
+		tap.pending.Lock()
+		tap.pending.m[id] = &tapPending{Id: id, Method: head.Method, Start: time.Now()}
+		tap.pending.Unlock()
+	case !out && head.Method == "" && id != "": // response
+		tap.pending.Lock()
+		if p, ok := tap.pending.m[id]; ok {
+			delete(tap.pending.m, id)
+			latency = time.Since(p.Start)
+			method = p.Method
+		}
+		tap.pending.Unlock()
+	}
+
+	man := tap.li.lang.man
+	if !man.hasTraces(tap.li.lang) {
+		return
+	}
+	msg := &tapMessage{}
+	if err := json.Unmarshal(b, msg); err != nil {
+		return
+	}
+	line := traceLine(msg, id, method, out, latency)
+	man.traceLine(tap.li.lang, tap.li.lang.WrapMsg(line))
+}
+
+// Sorted by start time.
+func (tap *clientTap) pendingRequests() []*tapPending {
+	tap.pending.Lock()
+	defer tap.pending.Unlock()
+	res := []*tapPending{}
+	for _, p := range tap.pending.m {
+		u := *p
+		res = append(res, &u)
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].Start.Before(res[b].Start)
+	})
+	return res
+}
+
+//----------
+
+type tapMessageHead struct {
+	Id     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+}
+
+type tapMessage struct {
+	Id     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Format: "--> req 12 textDocument/hover {...}", "<-- resp 12 textDocument/hover (34ms) {...}".
+func traceLine(msg *tapMessage, id, method string, out bool, latency time.Duration) string {
+	buf := &bytes.Buffer{}
+	dir := "<--"
+	if out {
+		dir = "-->"
+	}
+	fmt.Fprintf(buf, "%v ", dir)
+
+	payload := msg.Params
+	switch {
+	case msg.Method == "" && id != "":
+		fmt.Fprintf(buf, "resp %v", id)
+		if method != "" {
+			fmt.Fprintf(buf, " %v", method)
+		}
+		if latency >= 0 {
+			fmt.Fprintf(buf, " (%v)", latency.Round(time.Millisecond))
+		}
+		payload = msg.Result
+	case id != "":
+		fmt.Fprintf(buf, "req %v %v", id, msg.Method)
+	default:
+		fmt.Fprintf(buf, "notif %v", msg.Method)
+	}
+
+	if msg.Error != nil {
+		fmt.Fprintf(buf, " error(%v): %v", msg.Error.Code, msg.Error.Message)
+	} else if len(payload) > 0 {
+		fmt.Fprintf(buf, " %s", truncatePayload(payload, traceMaxPayload))
+	}
+	return buf.String()
+}
+
+func truncatePayload(b []byte, n int) []byte {
+	b = bytes.TrimSpace(b)
+	if len(b) <= n {
+		return b
+	}
+	u := append([]byte{}, b[:n]...)
+	return append(u, "..."+strconv.Itoa(len(b)-n)+" more bytes"...)
+}
+
+//----------
+
+// Extracts the content of the "Content-Length" framed messages from a stream.
+type tapFramer struct {
+	buf []byte
+}
+
+func (f *tapFramer) write(p []byte, fn func([]byte)) {
+	f.buf = append(f.buf, p...)
+	for {
+		i := bytes.Index(f.buf, []byte("\r\n\r\n"))
+		if i < 0 {
+			return
+		}
+		n, ok := tapContentLength(f.buf[:i])
+		if !ok {
+			f.buf = f.buf[:0] // out of sync, discard
+			return
+		}
+		start := i + 4
+		if len(f.buf) < start+n {
+			return // incomplete
+		}
+		fn(f.buf[start : start+n])
+		f.buf = append(f.buf[:0], f.buf[start+n:]...)
+	}
+}
+
+func tapContentLength(header []byte) (int, bool) {
+	for _, line := range bytes.Split(header, []byte("\r\n")) {
+		k, v, ok := bytes.Cut(line, []byte(":"))
+		if !ok || !bytes.EqualFold(bytes.TrimSpace(k), []byte("Content-Length")) {
+			continue
+		}
+		n, err := strconv.Atoi(string(bytes.TrimSpace(v)))
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+//----------
+
+type managerTrace struct {
+	lang *LangManager
+	w    io.Writer
+}
+
+// Writes the messages of the lang instances (all roots) to w until ctx is done.
+func (man *Manager) Trace(ctx context.Context, language string, w io.Writer) error {
+	lang, ok := man.langManagerByLanguage(language)
+	if !ok {
+		return fmt.Errorf("lsproto language not registered: %q", language)
+	}
+
+	man.traces.Lock()
+	man.traces.id++
+	id := man.traces.id
+	man.traces.m[id] = &managerTrace{lang: lang, w: w}
+	man.traces.Unlock()
+
+	defer func() {
+		man.traces.Lock()
+		delete(man.traces.m, id)
+		man.traces.Unlock()
+	}()
+
+	<-ctx.Done()
+	return nil
+}
+
+func (man *Manager) hasTraces(lang *LangManager) bool {
+	man.traces.Lock()
+	defer man.traces.Unlock()
+	for _, tr := range man.traces.m {
+		if tr.lang == lang {
+			return true
+		}
+	}
+	return false
+}
+
+func (man *Manager) traceLine(lang *LangManager, s string) {
+	man.traces.Lock()
+	defer man.traces.Unlock()
+	t := time.Now().Format("15:04:05.000")
+	for _, tr := range man.traces.m {
+		if tr.lang == lang {
+			fmt.Fprintf(tr.w, "%v %v\n", t, s)
+		}
+	}
+}
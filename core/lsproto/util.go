@@ -9,6 +9,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"sort"
 	"strings"
@@ -170,7 +171,7 @@ func jsonGetPath2(v any, args []string) (any, error) {
 	// handle last arg
 	if len(args) == 0 {
 		switch t := v.(type) {
-		case bool, int, float32, float64:
+		case bool, int, float32, float64, string, map[string]any, []any:
 			return t, nil
 		}
 		return nil, fmt.Errorf("unhandled last type: %T", v)
@@ -270,6 +271,60 @@ func ManagerCallHierarchyCallsToString(mcalls []*ManagerCallHierarchyCalls, typ
 
 //----------
 
+type ManagerTypeHierarchyTypes struct {
+	item  *TypeHierarchyItem
+	types []*TypeHierarchyItem
+}
+
+func ManagerTypeHierarchyTypesToString(mtypes []*ManagerTypeHierarchyTypes, typ TypeHierarchyType, baseDir string) (string, error) {
+	res := []string{}
+
+	// build title
+	s1 := "supertypes"
+	if typ == SubtypesTht {
+		s1 = "subtypes"
+	}
+	u := fmt.Sprintf("lsproto type hierarchy %s:", s1)
+	res = append(res, u)
+
+	for _, mt := range mtypes {
+		// build subtitle
+		s2 := fmt.Sprintf("%s of %v: %v results", s1, mt.item.Name, len(mt.types))
+		res = append(res, s2)
+
+		res2 := []string{}
+		for _, item := range mt.types {
+			filename, err := UrlToAbsFilename(string(item.Uri))
+			if err != nil {
+				return "", err
+			}
+			// use basedir to output filename
+			if baseDir != "" {
+				if u, err := filepath.Rel(baseDir, filename); err == nil {
+					filename = u
+				}
+			}
+
+			r := item.SelectionRange
+			if r == nil {
+				r = item.Range
+			}
+			line, col := 1, 1
+			if r != nil {
+				line, col = r.Start.OneBased()
+			}
+			u := fmt.Sprintf("\t%s:%d:%d: %s", filename, line, col, item.Name)
+			res2 = append(res2, u)
+		}
+		sort.Strings(res2)
+		res = append(res, res2...)
+	}
+	w := strings.Join(res, "\n")
+	return w, nil
+}
+
+//----------
+
 func LocationsToString(locations []*Location, baseDir string) (string, error) {
 	type loc2 struct { // for sorting
 		a    string
@@ -314,6 +369,166 @@ func LocationsToString(locations []*Location, baseDir string) (string, error) {
 
 //----------
 
+// Children are indented. Format: "file:line:col kind name".
+func DocumentSymbolsToString(filename string, syms []*DocumentSymbol, baseDir string) string {
+	filename = relFilename(filename, baseDir)
+	buf := &bytes.Buffer{}
+	var printSyms func([]*DocumentSymbol, int)
+	printSyms = func(syms []*DocumentSymbol, depth int) {
+		syms = append([]*DocumentSymbol{}, syms...)
+		sort.SliceStable(syms, func(a, b int) bool {
+			return positionLess(symbolPos(syms[a]), symbolPos(syms[b]))
+		})
+		for _, s := range syms {
+			pos := symbolPos(s)
+			line, col := pos.OneBased()
+			indent := strings.Repeat("\t", depth+1)
+			fmt.Fprintf(buf, "%v%v:%v:%v %v %v\n", indent, filename, line, col, s.Kind, s.Name)
+			printSyms(s.Children, depth+1)
+		}
+	}
+	printSyms(syms, 0)
+	return buf.String()
+}
+
+func symbolPos(s *DocumentSymbol) Position {
+	if s.SelectionRange != nil {
+		return s.SelectionRange.Start
+	}
+	if s.Range != nil {
+		return s.Range.Start
+	}
+	return Position{}
+}
+
+// Symbols are indented under their container if present in the results. Format: "file:line:col kind name".
+func WorkspaceSymbolsToString(syms []*SymbolInformation, baseDir string) (string, error) {
+	type sym2 struct {
+		filename string
+		pos      Position
+		si       *SymbolInformation
+		children []*sym2
+	}
+
+	res := []*sym2{}
+	for _, si := range syms {
+		if si.Location == nil {
+			continue
+		}
+		filename, err := UrlToAbsFilename(string(si.Location.Uri))
+		if err != nil {
+			return "", err
+		}
+		pos := Position{}
+		if si.Location.Range != nil {
+			pos = si.Location.Range.Start
+		}
+		res = append(res, &sym2{filename: filename, pos: pos, si: si})
+	}
+
+	sort.Slice(res, func(a, b int) bool {
+		if res[a].filename == res[b].filename {
+			return positionLess(res[a].pos, res[b].pos)
+		}
+		return res[a].filename < res[b].filename
+	})
+
+	// build hierarchy by container name (same file)
+	byName := map[string]*sym2{} // key: filename+name
+	for _, s := range res {
+		k := s.filename + "\x00" + s.si.Name
+		if _, ok := byName[k]; !ok {
+			byName[k] = s
+		}
+	}
+	roots := []*sym2{}
+	for _, s := range res {
+		if s.si.ContainerName != "" {
+			k := s.filename + "\x00" + s.si.ContainerName
+			if p, ok := byName[k]; ok && p != s {
+				p.children = append(p.children, s)
+				continue
+			}
+		}
+		roots = append(roots, s)
+	}
+
+	buf := &bytes.Buffer{}
+	var printSyms func([]*sym2, int)
+	printSyms = func(syms []*sym2, depth int) {
+		for _, s := range syms {
+			filename := relFilename(s.filename, baseDir)
+			line, col := s.pos.OneBased()
+			indent := strings.Repeat("\t", depth+1)
+			fmt.Fprintf(buf, "%v%v:%v:%v %v %v\n", indent, filename, line, col, s.si.Kind, s.si.Name)
+			printSyms(s.children, depth+1)
+		}
+	}
+	printSyms(roots, 0)
+	return buf.String(), nil
+}
+
+// Use basedir to output filename.
+func relFilename(filename, baseDir string) string {
+	if baseDir != "" {
+		if u, err := filepath.Rel(baseDir, filename); err == nil {
+			return u
+		}
+	}
+	return filename
+}
+
+//----------
+
+func DiagnosticsToString(fdiags []*FileDiagnostics, baseDir string) string {
+	buf := &bytes.Buffer{}
+	for _, fd := range fdiags {
+		filename := fd.Filename
+		// use basedir to output filename
+		if baseDir != "" {
+			if u, err := filepath.Rel(baseDir, filename); err == nil {
+				filename = u
+			}
+		}
+
+		// diagnostics without a range first
+		diags := append([]*Diagnostic{}, fd.Diagnostics...)
+		sort.SliceStable(diags, func(a, b int) bool {
+			r1, r2 := diags[a].Range, diags[b].Range
+			if r1 == nil || r2 == nil {
+				return r1 == nil && r2 != nil
+			}
+			p1, p2 := r1.Start, r2.Start
+			return p1.Line < p2.Line ||
+				(p1.Line == p2.Line && p1.Character < p2.Character)
+		})
+
+		for _, d := range diags {
+			if d.Range == nil {
+				fmt.Fprintf(buf, "\t%v: %v\n", filename, DiagnosticString(d))
+				continue
+			}
+			line, col := d.Range.Start.OneBased()
+			fmt.Fprintf(buf, "\t%v:%v:%v: %v\n", filename, line, col, DiagnosticString(d))
+		}
+	}
+	return buf.String()
+}
+
+// Single line string: "severity: message".
+func DiagnosticString(d *Diagnostic) string {
+	msg := d.Message
+	if i := strings.Index(msg, "\n"); i >= 0 {
+		msg = msg[:i]
+	}
+	if d.Source != "" {
+		msg = fmt.Sprintf("%v (%v)", msg, d.Source)
+	}
+	return fmt.Sprintf("%v: %v", d.Severity, msg)
+}
+
+//----------
+
 func CompletionListToString(clist *CompletionList) []string {
 	res := []string{}
 	for _, ci := range clist.Items {
@@ -342,6 +557,168 @@ func CompletionListToString(clist *CompletionList) []string {
 
 //----------
 
+// Each line can be clicked (content cmd) to apply the action.
+func CodeActionsToString(mcas []*ManagerCodeAction) string {
+	buf := &bytes.Buffer{}
+	for _, mca := range mcas {
+		ca := mca.Action
+		u := []string{}
+		if ca.Kind != "" {
+			u = append(u, ca.Kind)
+		}
+		if ca.IsPreferred {
+			u = append(u, "preferred")
+		}
+		extra := ""
+		if len(u) > 0 {
+			extra = fmt.Sprintf(" (%v)", strings.Join(u, ", "))
+		}
+		fmt.Fprintf(buf, "\tLsprotoCodeAction %v: %v%v\n", mca.Id, ca.Title, extra)
+	}
+	return buf.String()
+}
+
+//----------
+
+// Active signature with the active parameter emphasized, followed by the docs. Returns empty string if there are no signatures.
+func SignatureHelpToString(sh *SignatureHelp) string {
+	if sh == nil || len(sh.Signatures) == 0 {
+		return ""
+	}
+	si := sh.ActiveSignature
+	if si < 0 || si >= len(sh.Signatures) {
+		si = 0
+	}
+	sig := sh.Signatures[si]
+	ap := sh.ActiveParameter
+	if sig.ActiveParameter != nil {
+		ap = *sig.ActiveParameter
+	}
+
+	label := sig.Label
+	paramDoc := ""
+	if ap >= 0 && ap < len(sig.Parameters) {
+		param := sig.Parameters[ap]
+		if i, j, ok := parameterLabelIndexes(sig.Label, param); ok {
+			label = label[:i] + "«" + label[i:j] + "»" + label[j:]
+		}
+		paramDoc = strings.TrimSpace(param.Documentation.String())
+	}
+
+	u := []string{label}
+	if paramDoc != "" {
+		u = append(u, paramDoc)
+	}
+	if doc := strings.TrimSpace(sig.Documentation.String()); doc != "" {
+		u = append(u, doc)
+	}
+	return strings.Join(u, "\n\n")
+}
+
+// Byte indexes of the parameter in the signature label.
+func parameterLabelIndexes(sigLabel string, param *ParameterInformation) (int, int, bool) {
+	// label as a string
+	var str string
+	if err := json.Unmarshal(param.Label, &str); err == nil {
+		i := strings.Index(sigLabel, str)
+		if str == "" || i < 0 {
+			return 0, 0, false
+		}
+		return i, i + len(str), true
+	}
+	// label as utf16 offsets
+	var offs [2]int
+	if err := json.Unmarshal(param.Label, &offs); err != nil {
+		return 0, 0, false
+	}
+	u16 := utf16.Encode([]rune(sigLabel))
+	if offs[0] < 0 || offs[0] > offs[1] || offs[1] > len(u16) {
+		return 0, 0, false
+	}
+	i := len(string(utf16.Decode(u16[:offs[0]])))
+	j := len(string(utf16.Decode(u16[:offs[1]])))
+	return i, j, true
+}
+
+//----------
+
+// Label with the padding spaces.
+func InlayHintLabel(h *InlayHint) string {
+	s := ""
+	if err := json.Unmarshal(h.Label, &s); err != nil {
+		parts := []*InlayHintLabelPart{}
+		if err := json.Unmarshal(h.Label, &parts); err == nil {
+			for _, p := range parts {
+				s += p.Value
+			}
+		}
+	}
+	if h.PaddingLeft {
+		s = " " + s
+	}
+	if h.PaddingRight {
+		s += " "
+	}
+	return s
+}
+
+//----------
+
+// Markdown content is rendered as plain text.
+func HoverToString(h *Hover) string {
+	if h == nil {
+		return ""
+	}
+	u := []string{}
+	if mc := h.Contents.mc; mc != nil {
+		v := mc.Value
+		if mc.Kind == "markdown" {
+			v = MarkdownToPlainText(v)
+		}
+		u = append(u, v)
+	}
+	for _, ms := range h.Contents.mss {
+		v := ms.Value
+		if ms.Language == "" {
+			v = MarkdownToPlainText(v) // plain strings are markdown
+		}
+		u = append(u, v)
+	}
+	for i, v := range u {
+		u[i] = strings.TrimSpace(v)
+	}
+	return strings.TrimSpace(strings.Join(u, "\n\n"))
+}
+
+// Simple conversion: removes code fences, escapes, emphasis, and keeps the text of links.
+func MarkdownToPlainText(s string) string {
+	lines := strings.Split(s, "\n")
+	res := []string{}
+	inCode := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			res = append(res, line)
+			continue
+		}
+		line = strings.TrimLeft(line, "#")
+		line = markdownLinkRe.ReplaceAllString(line, "$1")
+		line = markdownEscapeRe.ReplaceAllString(line, "$1")
+		line = strings.ReplaceAll(line, "**", "")
+		line = strings.ReplaceAll(line, "`", "")
+		res = append(res, strings.TrimRight(line, " "))
+	}
+	return strings.Join(res, "\n")
+}
+
+var markdownLinkRe = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+var markdownEscapeRe = regexp.MustCompile("\\\\([\\\\`*_{}\\[\\]()#+\\-.!<>])")
+
+//----------
+
 func PatchTextEdits(src []byte, edits []*TextEdit) ([]byte, error) {
 	sortTextEdits(edits)
 	res := bytes.Buffer{} // resulting patched src
@@ -360,6 +737,36 @@ func PatchTextEdits(src []byte, edits []*TextEdit) ([]byte, error) {
 	return res.Bytes(), nil
 }
 
+// Applies the edits to rw (offsets are computed before any write). Writing from the last edit to the first keeps the offsets valid.
+func ApplyTextEdits(rw iorw.ReadWriterAt, edits []*TextEdit) error {
+	type ofs struct{ offset, n int }
+	sortTextEdits(edits)
+	u := make([]ofs, len(edits))
+	for i, e := range edits {
+		offset, n, err := RangeToOffsetLen(rw, e.Range)
+		if err != nil {
+			return err
+		}
+		u[i] = ofs{offset, n}
+	}
+	for i := len(edits) - 1; i >= 0; i-- {
+		if err := rw.OverwriteAt(u[i].offset, u[i].n, []byte(edits[i].NewText)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func positionLess(a, b Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
+
+func rangesOverlap(a, b *Range) bool {
+	return !positionLess(a.End, b.Start) && !positionLess(b.End, a.Start)
+}
+
+//----------
+
 func sortTextEdits(edits []*TextEdit) {
 	sort.Slice(edits, func(i, j int) bool {
 		p1, p2 := &edits[i].Range.Start, &edits[j].Range.Start
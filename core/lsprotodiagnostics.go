@@ -0,0 +1,66 @@
+package core
+
+import (
+	"sort"
+
+	"github.com/jmigpin/editor/core/lsproto"
+	"github.com/jmigpin/editor/util/drawutil"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// Not UI safe.
+func (ed *Editor) onLSProtoDiagnostics(filename string) {
+	ed.UI.RunOnUIGoRoutine(func() {
+		info, ok := ed.ERowInfo(filename)
+		if !ok {
+			return
+		}
+		for _, erow := range info.ERows {
+			erow.updateLSProtoDiagnostics()
+		}
+	})
+}
+
+//----------
+
+func (erow *ERow) updateLSProtoDiagnostics() {
+	if !erow.Info.IsFileButNotDir() {
+		return
+	}
+	diags := erow.Ed.LSProtoMan.Diagnostics(erow.Info.Name())
+	if len(diags) == 0 && !erow.lsprotoAnns.On() {
+		return // nothing to set or clear
+	}
+	ta := erow.Row.TextArea
+	entries := lsprotoDiagnosticsAnnotations(diags, ta.RW())
+	erow.Ed.SetAnnotations(AnnotatorLSProto, ta, -1, entries)
+}
+
+//----------
+
+func lsprotoDiagnosticsAnnotations(diags []*lsproto.Diagnostic, rd iorw.ReaderAt) *drawutil.AnnotationGroup {
+	anns := []*drawutil.Annotation{}
+	for _, d := range diags {
+		if d.Range == nil {
+			continue
+		}
+		offset, _, err := lsproto.RangeToOffsetLen(rd, d.Range)
+		if err != nil {
+			continue // diagnostic for a different version of the content
+		}
+		s := lsproto.DiagnosticString(d)
+		anns = append(anns, &drawutil.Annotation{Offset: offset, Bytes: []byte(s)})
+	}
+	if len(anns) == 0 {
+		return nil
+	}
+
+	// must be ordered by offset
+	sort.SliceStable(anns, func(a, b int) bool {
+		return anns[a].Offset < anns[b].Offset
+	})
+
+	entries := drawutil.NewAnnotationGroup(0)
+	entries.Anns = anns
+	return entries
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"github.com/jmigpin/editor/core/lsproto"
+	"github.com/jmigpin/editor/util/uiutil/widget"
+)
+
+// Applies the changes to the files open in rows (the edits can be undone, and need to be saved). Returns the changes for the files that are not open. Not UI safe.
+func (ed *Editor) lsprotoApplyOpenEdits(wecs []*lsproto.WorkspaceEditChange) ([]*lsproto.WorkspaceEditChange, error) {
+	rest := []*lsproto.WorkspaceEditChange{}
+	var err error
+	ed.UI.WaitRunOnUIGoRoutine(func() {
+		for _, wec := range wecs {
+			info, ok := ed.ERowInfo(wec.Filename)
+			if !ok || !info.IsFileButNotDir() {
+				rest = append(rest, wec)
+				continue
+			}
+			erow, ok := info.FirstERow()
+			if !ok {
+				rest = append(rest, wec)
+				continue
+			}
+			if err = LSProtoApplyTextEdits(erow.Row.TextArea.TextEdit, wec.Edits); err != nil {
+				return
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rest, nil
+}
+
+//----------
+
+// Applies the edits as one undo group, keeping the cursor position. UI safe.
+func LSProtoApplyTextEdits(te *widget.TextEdit, edits []*lsproto.TextEdit) error {
+	pos := widget.GetStableCursorPos(te.RW(), te.CursorIndex())
+	te.BeginUndoGroup()
+	defer te.EndUndoGroup()
+	if err := lsproto.ApplyTextEdits(te.RW(), edits); err != nil {
+		return err
+	}
+	te.Cursor().SetSelectionOff()
+	te.SetCursorIndex(widget.FindStableCursorIndex(te.RW(), pos))
+	return nil
+}
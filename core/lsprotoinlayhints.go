@@ -0,0 +1,149 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmigpin/editor/util/drawutil"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// delay after the last write/scroll before requesting new hints
+var lsprotoInlayHintsDelay = 300 * time.Millisecond
+
+type ERowInlayHints struct {
+	on    bool
+	gen   int // incremented on writes/requests, discards outdated results
+	timer *time.Timer
+	view  [2]int // rune offset and height of the last requested view
+}
+
+//----------
+
+// UI safe.
+func (erow *ERow) enableLSProtoInlayHints(v bool) {
+	st := &erow.lsprotoInlayHints
+	if st.on == v {
+		return
+	}
+	st.on = v
+	st.gen++
+	if v {
+		erow.updateLSProtoInlayHints()
+	} else {
+		erow.stopLSProtoInlayHintsTimer()
+		erow.Row.TextArea.SetVirtualText(nil)
+	}
+}
+
+// UI safe. Keeps the current hints in place (shifted) until new hints arrive.
+func (erow *ERow) lsprotoInlayHintsOnWrite(ev *iorw.RWEvWrite) {
+	st := &erow.lsprotoInlayHints
+	if !st.on {
+		return
+	}
+	st.gen++
+
+	ta := erow.Row.TextArea
+	if vts := ta.VirtualText(); len(vts) > 0 {
+		ta.SetVirtualText(shiftVirtualText(vts, ev))
+	}
+
+	erow.scheduleLSProtoInlayHints()
+}
+
+// UI safe. Requests the hints for the new view after scrolling/resizing.
+func (erow *ERow) lsprotoInlayHintsOnLayout() {
+	st := &erow.lsprotoInlayHints
+	if !st.on {
+		return
+	}
+	if st.view != erow.lsprotoInlayHintsView() {
+		erow.scheduleLSProtoInlayHints()
+	}
+}
+
+func (erow *ERow) lsprotoInlayHintsView() [2]int {
+	ta := erow.Row.TextArea
+	return [2]int{ta.RuneOffset(), ta.Bounds.Dy()}
+}
+
+func (erow *ERow) scheduleLSProtoInlayHints() {
+	st := &erow.lsprotoInlayHints
+	erow.stopLSProtoInlayHintsTimer()
+	st.timer = time.AfterFunc(lsprotoInlayHintsDelay, func() {
+		erow.Ed.UI.RunOnUIGoRoutine(erow.updateLSProtoInlayHints)
+	})
+}
+
+func (erow *ERow) stopLSProtoInlayHintsTimer() {
+	st := &erow.lsprotoInlayHints
+	if st.timer != nil {
+		st.timer.Stop()
+		st.timer = nil
+	}
+}
+
+//----------
+
+// UI safe. Requests the hints for the visible range.
+func (erow *ERow) updateLSProtoInlayHints() {
+	st := &erow.lsprotoInlayHints
+	if !st.on || erow.ctx.Err() != nil || !erow.Info.IsFileButNotDir() {
+		return
+	}
+	// must have a registration that handles the filename
+	if _, err := erow.Ed.LSProtoMan.LangManager(erow.Info.Name()); err != nil {
+		return
+	}
+
+	st.gen++
+	gen := st.gen
+	st.view = erow.lsprotoInlayHintsView()
+
+	ta := erow.Row.TextArea
+	offset := ta.RuneOffset()
+	n := ta.Drawer.ScrollViewSize().Y
+	n = min(n, ta.RW().Max()-offset)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(erow.ctx, 8*time.Second)
+		defer cancel()
+
+		hints, err := erow.Ed.LSProtoMan.TextDocumentInlayHints(ctx, erow.Info.Name(), ta.RW(), offset, n)
+
+		erow.Ed.UI.RunOnUIGoRoutine(func() {
+			if st.gen != gen {
+				return // content changed meanwhile, or a newer request
+			}
+			if err != nil {
+				ta.SetVirtualText(nil)
+				return
+			}
+			vts := []*drawutil.VirtualText{}
+			for _, h := range hints {
+				vt := &drawutil.VirtualText{Offset: h.Offset, Bytes: []byte(h.Label)}
+				vts = append(vts, vt)
+			}
+			ta.SetVirtualText(vts)
+		})
+	}()
+}
+
+//----------
+
+// Adjusts the offsets to a write. Entries inside the deleted range are removed.
+func shiftVirtualText(vts []*drawutil.VirtualText, ev *iorw.RWEvWrite) []*drawutil.VirtualText {
+	vts2 := make([]*drawutil.VirtualText, 0, len(vts))
+	for _, vt := range vts {
+		vt2 := *vt
+		switch {
+		case vt2.Offset >= ev.Index+ev.Dn:
+			vt2.Offset += ev.In - ev.Dn
+		case vt2.Offset > ev.Index:
+			continue
+		}
+		vts2 = append(vts2, &vt2)
+	}
+	return vts2
+}
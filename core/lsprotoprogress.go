@@ -0,0 +1,49 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmigpin/editor/core/lsproto"
+	"github.com/jmigpin/editor/ui"
+)
+
+func (ed *Editor) onLSProtoProgress(p *lsproto.ManagerProgress) {
+	if p.Filename == "" {
+		return // server initiated, only shown in the messages
+	}
+	ed.UI.RunOnUIGoRoutine(func() {
+		info, ok := ed.ERowInfo(p.Filename)
+		if !ok {
+			return
+		}
+		for _, erow := range info.ERows {
+			erow.updateLSProtoProgress()
+		}
+	})
+}
+
+//----------
+
+func (erow *ERow) updateLSProtoProgress() {
+	p := erow.Ed.LSProtoMan.FileProgress(erow.Info.Name())
+	if p != nil {
+		erow.Row.Toolbar.Square.SetProgressPercentage(p.Percentage)
+	}
+	erow.Row.SetState(ui.RowStateLSProtoProgress, p != nil)
+}
+
+// UI safe.
+func (erow *ERow) cancelLSProtoProgress() {
+	if !erow.Row.HasState(ui.RowStateLSProtoProgress) {
+		return
+	}
+	filename := erow.Info.Name()
+	go func() {
+		ctx, cancel := context.WithTimeout(erow.ctx, 8*time.Second)
+		defer cancel()
+		if err := erow.Ed.LSProtoMan.CancelProgress(ctx, filename); err != nil {
+			erow.Ed.Error(err)
+		}
+	}()
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmigpin/editor/core/lsproto"
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/drawutil"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// delay after the last write before requesting new tokens
+var lsprotoSemanticTokensDelay = 500 * time.Millisecond
+
+type ERowSemanticTokens struct {
+	on    bool
+	gen   int // incremented on writes/requests, discards outdated results
+	timer *time.Timer
+}
+
+//----------
+
+// UI safe.
+func (erow *ERow) enableLSProtoSemanticTokens(v bool) {
+	st := &erow.lsprotoSemTokens
+	if st.on == v {
+		return
+	}
+	st.on = v
+	st.gen++
+	if v {
+		erow.updateLSProtoSemanticTokens()
+	} else {
+		erow.stopLSProtoSemanticTokensTimer()
+		erow.Row.TextArea.SetSemanticColorOps(nil)
+	}
+}
+
+// UI safe. Keeps the current colors in place (shifted) until new tokens arrive.
+func (erow *ERow) lsprotoSemanticTokensOnWrite(ev *iorw.RWEvWrite) {
+	st := &erow.lsprotoSemTokens
+	if !st.on {
+		return
+	}
+	st.gen++
+
+	ta := erow.Row.TextArea
+	if ops := ta.SemanticColorOps(); len(ops) > 0 {
+		ta.SetSemanticColorOps(shiftColorizeOps(ops, ev))
+	}
+
+	erow.stopLSProtoSemanticTokensTimer()
+	st.timer = time.AfterFunc(lsprotoSemanticTokensDelay, func() {
+		erow.Ed.UI.RunOnUIGoRoutine(erow.updateLSProtoSemanticTokens)
+	})
+}
+
+func (erow *ERow) stopLSProtoSemanticTokensTimer() {
+	st := &erow.lsprotoSemTokens
+	if st.timer != nil {
+		st.timer.Stop()
+		st.timer = nil
+	}
+}
+
+//----------
+
+// UI safe. On failure (ex: server without semantic tokens support), the colors are cleared and only the syntax highlight is used.
+func (erow *ERow) updateLSProtoSemanticTokens() {
+	st := &erow.lsprotoSemTokens
+	if !st.on || erow.ctx.Err() != nil || !erow.Info.IsFileButNotDir() {
+		return
+	}
+	// must have a registration that handles the filename
+	if _, err := erow.Ed.LSProtoMan.LangManager(erow.Info.Name()); err != nil {
+		return
+	}
+
+	st.gen++
+	gen := st.gen
+	ta := erow.Row.TextArea
+
+	go func() {
+		ctx, cancel := context.WithTimeout(erow.ctx, 8*time.Second)
+		defer cancel()
+
+		toks, err := erow.Ed.LSProtoMan.TextDocumentSemanticTokens(ctx, erow.Info.Name(), ta.RW())
+
+		erow.Ed.UI.RunOnUIGoRoutine(func() {
+			if st.gen != gen {
+				return // content changed meanwhile, or a newer request
+			}
+			if err != nil {
+				ta.SetSemanticColorOps(nil)
+				return
+			}
+			ta.SetSemanticColorOps(lsprotoSemanticTokensColorOps(ta, toks))
+		})
+	}()
+}
+
+//----------
+
+func lsprotoSemanticTokensColorOps(ta *ui.TextArea, toks []*lsproto.SemanticToken) []*drawutil.ColorizeOp {
+	ops := []*drawutil.ColorizeOp{}
+	for _, tok := range toks {
+		name := lsprotoSemanticTokenColorName(tok)
+		if name == "" {
+			continue
+		}
+		fg := ta.TreeThemePaletteColor(name)
+		if fg == nil {
+			continue
+		}
+		ops = append(ops,
+			&drawutil.ColorizeOp{Offset: tok.Offset, Fg: fg},
+			&drawutil.ColorizeOp{Offset: tok.Offset + tok.Len},
+		)
+	}
+	return ops
+}
+
+func lsprotoSemanticTokenColorName(tok *lsproto.SemanticToken) string {
+	switch tok.Type {
+	case "keyword", "modifier":
+		return "text_colorize_keyword_fg"
+	case "type", "class", "enum", "interface", "struct", "typeParameter":
+		return "text_colorize_type_fg"
+	case "function", "method", "macro":
+		return "text_colorize_function_fg"
+	case "parameter":
+		return "text_colorize_parameter_fg"
+	case "enumMember":
+		return "text_colorize_constant_fg"
+	case "variable", "property":
+		if tok.HasModifier("readonly") {
+			return "text_colorize_constant_fg"
+		}
+	}
+	return ""
+}
+
+//----------
+
+// Adjusts the ops offsets to a write. Ops inside the deleted range are moved to the write index.
+func shiftColorizeOps(ops []*drawutil.ColorizeOp, ev *iorw.RWEvWrite) []*drawutil.ColorizeOp {
+	ops2 := make([]*drawutil.ColorizeOp, 0, len(ops))
+	for _, op := range ops {
+		op2 := *op
+		switch {
+		case op2.Offset >= ev.Index+ev.Dn:
+			op2.Offset += ev.In - ev.Dn
+		case op2.Offset > ev.Index:
+			op2.Offset = ev.Index
+		}
+		ops2 = append(ops2, &op2)
+	}
+	return ops2
+}
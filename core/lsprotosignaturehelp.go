@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmigpin/editor/core/lsproto"
+	"github.com/jmigpin/editor/util/uiutil/event"
+)
+
+// Requests signature help when typing "(" or ",", and closes it on ")".
+func (erow *ERow) lsprotoSignatureHelpOnKeyDown(ev *event.KeyDown) {
+	if !erow.Info.IsFileButNotDir() {
+		return
+	}
+	if ev.Mods.ClearLocks().HasAny(event.ModCtrl | event.ModAlt) {
+		return
+	}
+	if !ev.Point.In(erow.Row.TextArea.Bounds) {
+		return
+	}
+	switch ev.Rune {
+	case '(', ',':
+		// must have a registration that handles the filename
+		if _, err := erow.Ed.LSProtoMan.LangManager(erow.Info.Name()); err != nil {
+			return
+		}
+		// run after the rune is inserted
+		erow.Ed.UI.RunOnUIGoRoutine(func() {
+			erow.Ed.lsprotoSignatureHelp(erow, string(ev.Rune))
+		})
+	case ')':
+		if erow.Ed.ifbw.keepOnKeys {
+			erow.Ed.cancelInfoFloatBox()
+		}
+	}
+}
+
+//----------
+
+// UI safe.
+func (ed *Editor) lsprotoSignatureHelp(erow *ERow, triggerChar string) {
+	ta := erow.Row.TextArea
+	ci := ta.CursorIndex()
+	ctx := ed.ifbw.NewCtx(erow.ctx)
+
+	go func() {
+		ctx2, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+
+		sh, err := ed.LSProtoMan.TextDocumentSignatureHelp(ctx2, erow.Info.Name(), ta.RW(), ci, triggerChar)
+		if err != nil {
+			return // best effort, don't disturb typing with errors
+		}
+		s := lsproto.SignatureHelpToString(sh)
+
+		ed.UI.RunOnUIGoRoutine(func() {
+			if ctx.Err() != nil {
+				return // canceled meanwhile
+			}
+			if s == "" {
+				if ed.ifbw.keepOnKeys {
+					ed.cancelInfoFloatBox()
+				}
+				return
+			}
+			cfb := ed.ifbw.ui()
+			cfb.SetRefPointToTextAreaCursor(ta)
+			cfb.TextArea.ClearPos()
+			cfb.SetStrClearHistory(s)
+			cfb.Show()
+			ed.ifbw.keepOnKeys = true
+		})
+	}()
+}
@@ -167,6 +167,7 @@ func toolbarImportantVariableSpans(src string) [][2]int {
 	important := map[string]bool{
 		"$colorize":   true,
 		"$font":       true,
+		"$inlayHints": true,
 		"$scrollMode": true,
 		"$terminal":   true,
 	}
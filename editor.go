@@ -59,7 +59,7 @@ func main() {
 	flag.StringVar(&opt.EmuExec, "emuexec", "", "shell command to run when starting with -startterminalemu")
 	flag.BoolVar(&opt.UseMultiKey, "usemultikey", false, "use multi-key to compose characters (Ex: [multi-key, ~, a] = ã)")
 	flag.StringVar(&opt.Plugins, "plugins", "", "comma separated string of plugin filenames")
-	flag.Var(&opt.LSProtos, "lsproto", "Language-server-protocol register options. Can be specified multiple times.\nFormat: language,fileExtensions,network{tcp|tcpclient|stdio},command,optional{stderr,nogotoimpl}\nFormat notes:\n\tif network is tcp, the command runs in a template with vars: {{.Addr}}.\n\tif network is tcpclient, the command should be an ipaddress.\nExamples:\n\t"+strings.Join(lsproto.RegistrationExamples(), "\n\t"))
+	flag.Var(&opt.LSProtos, "lsproto", "Language-server-protocol register options. Can be specified multiple times.\nFormat: language,fileExtensions,network{tcp|tcpclient|stdio},command,optional{stderr,nogotoimpl,format,sharedroots,root:<marker>},config{json}\nFormat notes:\n\tthe optional config json (a field starting with \"{\") answers the server \"workspace/configuration\" requests.\n\tif network is tcp, the command runs in a template with vars: {{.Addr}}.\n\tif network is tcpclient, the command should be an ipaddress.\n\tthe optional root:<marker> values (ex: root:go.mod) detect the project root of a file, one server instance runs per root (defaults exist for go, c/cpp and python). With sharedroots, one instance is used and the roots are added as workspace folders.\n\tlanguages registered with the same file extension run together, the first is used for single results and the others have their results merged (ex: diagnostics, references, code actions).\nExamples:\n\t"+strings.Join(lsproto.RegistrationExamples(), "\n\t"))
 	flag.Var(&opt.PreSaveHooks, "presavehook", "Run program before saving a file. Uses stdin/stdout. Can be specified multiple times. By default, a \"goimports\" entry is auto added if no entry is defined for the \"go\" language.\nFormat: language,fileExtensions,cmd\nExamples:\n"+
 		"\tgo,.go,goimports\n"+
 		"\tcpp,\".cpp .hpp\",\"\\\"clang-format --style={'opt1':1,'opt2':2}\\\"\"\n"+
@@ -13,10 +13,11 @@ type RowSquare struct {
 	Size  image.Point
 	row   *Row
 	state RowState
+	perc  int // progress percentage, -1 if unknown
 }
 
 func NewRowSquare(row *Row) *RowSquare {
-	sq := &RowSquare{row: row, Size: image.Point{5, 5}}
+	sq := &RowSquare{row: row, Size: image.Point{5, 5}, perc: -1}
 	sq.Cursor = event.CloseCursor
 	return sq
 }
@@ -40,6 +41,16 @@ func (sq *RowSquare) Paint() {
 	}
 	imageutil.FillRectangle(img, sq.Bounds, bg)
 
+	// progress (bottom-up fill)
+	if sq.state.hasAny(RowStateLSProtoProgress) {
+		r := sq.Bounds
+		if sq.perc >= 0 && sq.perc < 100 {
+			r.Min.Y = r.Max.Y - r.Dy()*sq.perc/100
+		}
+		c := sq.TreeThemePaletteColor("rs_lsproto_progress")
+		imageutil.FillRectangle(img, r, c)
+	}
+
 	// mini-squares
 	if sq.state.hasAny(RowStateActive) {
 		r := sq.miniSq(0)
@@ -109,6 +120,12 @@ func (sq *RowSquare) SetState(s RowState, v bool) {
 		sq.MarkNeedsPaint()
 	}
 }
+func (sq *RowSquare) SetProgressPercentage(v int) {
+	if sq.perc != v {
+		sq.perc = v
+		sq.MarkNeedsPaint()
+	}
+}
 func (sq *RowSquare) HasState(s RowState) bool {
 	return sq.state.hasAny(s)
 }
@@ -155,4 +172,5 @@ const (
 	RowStateDuplicateHighlight
 	RowStateAnnotations
 	RowStateAnnotationsEdited
+	RowStateLSProtoProgress
 )
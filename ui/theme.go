@@ -38,6 +38,11 @@ func lightThemeColorsPal() widget.Palette {
 		"text_colorize_comments_fg":   cint(0x008b00), // green
 		"text_colorize_git_add_fg":    cint(0x008b00), // green
 		"text_colorize_git_delete_fg": cint(0x8b0000), // red
+		"text_colorize_keyword_fg":    cint(0x00008b), // blue
+		"text_colorize_type_fg":       cint(0x008b8b), // cyan
+		"text_colorize_function_fg":   cint(0x5c3317), // brown
+		"text_colorize_parameter_fg":  cint(0x8b008b), // magenta
+		"text_colorize_constant_fg":   cint(0xb8510b), // orange
 		"text_highlightword_fg":       nil,
 		"text_highlightword_bg":       cint(0xc6ee9e), // green
 		"text_wrapline_fg":            cint(0x0),
@@ -97,6 +102,11 @@ func acmeThemeColorsPal() widget.Palette {
 		"text_colorize_comments_fg":   cint(0x007500), // green
 		"text_colorize_git_add_fg":    cint(0x007500), // green
 		"text_colorize_git_delete_fg": cint(0x8b0000), // red
+		"text_colorize_keyword_fg":    cint(0x000075), // blue
+		"text_colorize_type_fg":       cint(0x007575), // cyan
+		"text_colorize_function_fg":   cint(0x5c3317), // brown
+		"text_colorize_parameter_fg":  cint(0x750075), // magenta
+		"text_colorize_constant_fg":   cint(0x9c4509), // orange
 		"text_highlightword_fg":       nil,
 		"text_highlightword_bg":       cint(0xc6ee9e), // green
 		"text_wrapline_fg":            cint(0x0),
@@ -204,6 +214,7 @@ func rowSquarePalette() widget.Palette {
 		"rs_duplicate_highlight": cint(0xffff00),                       // yellow
 		"rs_annotations":         cint(0xd35400),                       // pumpkin
 		"rs_annotations_edited":  imageutil.Tint(cint(0xd35400), 0.45), // pumpkin (brighter)
+		"rs_lsproto_progress":    cint(0x8e44ad),                       // purple
 	}
 	return pal
 }
@@ -43,6 +43,7 @@ type Drawer struct {
 		indexOf            IndexOf     // end
 		colorize           Colorize    // init
 		decorations        Decorations // insert
+		virtualText        VirtualText // insert
 		annotations        Annotations // insert
 		annotationsIndexOf AnnotationsIndexOf
 	}
@@ -159,6 +160,11 @@ type State struct {
 	decorations struct {
 		indexes []int
 	}
+	virtualText struct {
+		ei        int  // current entries index
+		inside    bool // inserting virtual text
+		lineStart bool // line start state of the rune after the virtual text
+	}
 	annotations struct {
 		cei    int // current entries index (to add to q)
 		indexQ []int
@@ -206,6 +212,7 @@ func New() *Drawer {
 	d.iters.indexOf.d = d
 	d.iters.colorize.d = d
 	d.iters.decorations.d = d
+	d.iters.virtualText.d = d
 	d.iters.annotations.d = d
 	d.iters.annotationsIndexOf.d = d
 	return d
@@ -452,6 +459,7 @@ func (d *Drawer) Draw(img draw.Image) {
 		&d.iters.lineWrap,
 		&d.iters.earlyExit, // after iters that change pen.Y
 		&d.iters.indent,
+		&d.iters.virtualText, // after iters that change the line
 		&d.iters.decorations, // after iters that change the line
 		&d.iters.annotations, // after iters that change the line
 		&d.iters.textContrast,
@@ -978,6 +986,7 @@ func (d *Drawer) sIters(earlyExit bool, more ...Iterator) []Iterator {
 		iters = append(iters, &d.iters.earlyExit)
 	}
 	iters = append(iters, &d.iters.indent)
+	iters = append(iters, &d.iters.virtualText) // changes pen.X
 	iters = append(iters, more...)
 	return iters
 }
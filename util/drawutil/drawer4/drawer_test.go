@@ -359,6 +359,53 @@ func TestLocalIndexOfCursorHalfHit(t *testing.T) {
 	}
 }
 
+func TestVirtualText1(t *testing.T) {
+	d, _ := newTestDrawerRect(image.Rect(0, 0, 200, 100))
+	r := iorw.NewStringReaderAt("ab\ncd")
+	d.SetReader(r)
+
+	p1 := d.LocalPointOf(1)
+	p4 := d.LocalPointOf(4)
+
+	d.Opt.VirtualText.On = true
+	d.Opt.VirtualText.Entries = []*drawutil.VirtualText{
+		{Offset: 1, Bytes: []byte("xyz")},
+		{Offset: 3, Bytes: []byte("w")}, // at line start
+	}
+
+	// runes after the virtual text are shifted
+	q1 := d.LocalPointOf(1)
+	if q1.Y != p1.Y || q1.X <= p1.X {
+		t.Fatalf("%v, %v", p1, q1)
+	}
+	q4 := d.LocalPointOf(4)
+	if q4.Y != p4.Y || q4.X <= p4.X {
+		t.Fatalf("%v, %v", p4, q4)
+	}
+	// indexes are not affected
+	inside := image.Point{2, 2}
+	if got := d.LocalIndexOf(q1.Add(inside)); got != 1 {
+		t.Fatalf("got %v", got)
+	}
+	if got := d.LocalIndexOf(q4.Add(inside)); got != 4 {
+		t.Fatalf("got %v", got)
+	}
+	// line starts are not affected
+	if w := d.wlineStartIndex(true, 4, 0, nil); w != 3 {
+		t.Fatalf("got %v", w)
+	}
+	if w := d.wlineStartIndex(true, 3, 1, nil); w != 0 {
+		t.Fatalf("got %v", w)
+	}
+	d.SetRuneOffset(3)
+	if got := d.RuneOffset(); got != 3 {
+		t.Fatalf("got %v", got)
+	}
+	if got := d.LocalIndexOf(d.LocalPointOf(4).Add(inside)); got != 4 {
+		t.Fatalf("got %v", got)
+	}
+}
+
 func TestImg13Cursor(t *testing.T) {
 	d, img := newTestDrawer()
 
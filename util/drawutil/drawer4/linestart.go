@@ -24,6 +24,10 @@ func (ls *LineStart) Iter() {
 	if ls.d.st.runeR.ri >= st.offset {
 		// don't stop before postLineWrap
 		if !ls.d.st.lineWrap.preLineWrap {
+			// stopping at the virtual text inserted before the rune
+			if ls.d.st.virtualText.inside && ls.d.st.virtualText.lineStart {
+				st.q = append(st.q, ls.d.st.runeR.ri)
+			}
 			ls.d.iterStop()
 			return
 		}
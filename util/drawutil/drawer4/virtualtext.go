@@ -0,0 +1,90 @@
+package drawer4
+
+import "github.com/jmigpin/editor/util/drawutil"
+
+type VirtualText struct {
+	d *Drawer
+}
+
+func (vt *VirtualText) Init() {}
+
+func (vt *VirtualText) Iter() {
+	if vt.d.Opt.VirtualText.On {
+		if vt.d.iters.runeR.isNormal() && !vt.d.st.lineWrap.preLineWrap {
+			if !vt.iter2() {
+				return
+			}
+		}
+	}
+	if !vt.d.iterNext() {
+		return
+	}
+}
+
+func (vt *VirtualText) End() {}
+
+//----------
+
+func (vt *VirtualText) iter2() bool {
+	entries := vt.d.Opt.VirtualText.Entries
+	ri := vt.d.st.runeR.ri
+	i := &vt.d.st.virtualText.ei
+	// past entries
+	for *i < len(entries) && entries[*i].Offset < ri {
+		*i++
+	}
+	// insert entries at the rune offset (before the rune)
+	for ; *i < len(entries) && entries[*i].Offset == ri; *i++ {
+		if !vt.insertVirtualText(entries[*i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (vt *VirtualText) insertVirtualText(e *VirtualTextEntry) (ok bool) {
+	// keep state, continue with the current rune at the new pen.X
+	st := vt.d.st.runeR
+	defer func() {
+		penX := vt.d.st.runeR.pen.X
+		vt.d.st.runeR = st
+		if !ok {
+			return // stopped, state as before the insertion
+		}
+		vt.d.st.runeR.pen.X = penX
+		// recalc (tab) advance after the insertion
+		vt.d.st.runeR.advance = vt.d.iters.runeR.tabbedGlyphAdvance(st.ru)
+	}()
+
+	// keep/restore color state
+	cc := vt.d.st.curColors
+	defer func() { vt.d.st.curColors = cc }()
+	vt.d.st.curColors.fg = vt.d.fg
+	vt.d.st.curColors.bg = nil
+	assignColor(&vt.d.st.curColors.fg, vt.d.Opt.VirtualText.Fg)
+	assignColor(&vt.d.st.curColors.bg, vt.d.Opt.VirtualText.Bg)
+
+	// not a line start for the inserted runes (avoid counting the line twice)
+	ls := vt.d.st.line.lineStart
+	defer func() { vt.d.st.line.lineStart = ls }()
+	vt.d.st.line.lineStart = false
+	vt.d.st.virtualText.lineStart = ls
+
+	vt.d.st.virtualText.inside = true
+	defer func() { vt.d.st.virtualText.inside = false }()
+
+	// zero size runes: keep the rune index at the offset
+	rr := &vt.d.iters.runeR
+	rr.pushExtra()
+	defer rr.popExtra()
+	for _, ru := range string(e.Bytes) {
+		if !rr.iter2(ru, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+//----------
+
+type VirtualTextEntry = drawutil.VirtualText
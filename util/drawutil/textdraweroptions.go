@@ -42,6 +42,11 @@ type TextDrawerOptions struct {
 		}
 		Entries *AnnotationGroup // must be ordered by offset
 	}
+	VirtualText struct {
+		On      bool
+		Fg, Bg  color.Color
+		Entries []*VirtualText // must be ordered by offset
+	}
 	WordHighlight struct {
 		On     bool
 		Fg, Bg color.Color
@@ -110,6 +115,14 @@ const (
 
 //----------
 
+// Text drawn before the rune at offset, not part of the content (ex: lsproto inlay hints).
+type VirtualText struct {
+	Offset int
+	Bytes  []byte
+}
+
+//----------
+
 type AnnotationGroup struct {
 	sync.RWMutex
 	Anns []*Annotation
@@ -42,13 +42,15 @@ func NewTextEditX(uiCtx UIContext) *TextEditX {
 	// setup colorize order
 	opt.Colorize.Groups = []*drawutil.ColorizeGroup{
 		&opt.SyntaxHighlight.Group,
+		{}, // 1=semantic
 		&opt.ContentColorize.Group,
-		{}, // 2=extra
-		{}, // 3=terminal
+		{}, // 3=extra
+		{}, // 4=terminal
 		&opt.WordHighlight.Group,
 		&opt.ParenthesisHighlight.Group,
-		{}, // 6=selection
-		{}, // 7=flash
+		{}, // 7=annotations
+		{}, // 8=selection
+		{}, // 9=flash
 	}
 	opt.Decorations.Groups = []*drawutil.DecorationGroup{
 		{}, // 0=terminal
@@ -58,10 +60,12 @@ func NewTextEditX(uiCtx UIContext) *TextEditX {
 }
 
 const (
-	cgIdxExtra     = 2
-	cgIdxTerm      = 3
-	cgIdxSelection = 6
-	cgIdxFlash     = 7
+	cgIdxSemantic    = 1
+	cgIdxExtra       = 3
+	cgIdxTerm        = 4
+	cgIdxAnnotations = 7
+	cgIdxSelection   = 8
+	cgIdxFlash       = 9
 
 	dgIdxTerm = 0
 )
@@ -73,6 +77,33 @@ func (te *TextEditX) SetExtraColorOps(ops []*drawutil.ColorizeOp) {
 	te.MarkNeedsPaint()
 }
 
+// Colors from a semantic analysis of the content (ex: lsproto semantic tokens). Drawn over the syntax highlight.
+func (te *TextEditX) SetSemanticColorOps(ops []*drawutil.ColorizeOp) {
+	te.Text.Drawer.TextDrawerOptions().Colorize.Groups[cgIdxSemantic].Ops = ops
+	te.MarkNeedsPaint()
+}
+func (te *TextEditX) SemanticColorOps() []*drawutil.ColorizeOp {
+	return te.Text.Drawer.TextDrawerOptions().Colorize.Groups[cgIdxSemantic].Ops
+}
+
+// Colors associated with the annotations (ex: godebug heatmap). Drawn under the selection.
+func (te *TextEditX) SetAnnotationsColorOps(ops []*drawutil.ColorizeOp) {
+	te.Text.Drawer.TextDrawerOptions().Colorize.Groups[cgIdxAnnotations].Ops = ops
+	te.MarkNeedsPaint()
+}
+
+// Text drawn inline that is not part of the content (ex: lsproto inlay hints). Entries must be ordered by offset.
+func (te *TextEditX) SetVirtualText(entries []*drawutil.VirtualText) {
+	opt := te.Drawer.TextDrawerOptions()
+	opt.VirtualText.On = len(entries) > 0
+	opt.VirtualText.Entries = entries
+	te.Drawer.TextDrawerOptionsChanged()
+	te.MarkNeedsPaint()
+}
+func (te *TextEditX) VirtualText() []*drawutil.VirtualText {
+	return te.Drawer.TextDrawerOptions().VirtualText.Entries
+}
+
 //----------
 
 func (te *TextEditX) PaintBase() {
@@ -332,6 +363,10 @@ func (te *TextEditX) OnThemeChange() {
 	opt.Annotations.Selected.Fg = pcol("text_annotations_select_fg")
 	opt.Annotations.Selected.Bg = pcol("text_annotations_select_bg")
 
+	// virtual text
+	opt.VirtualText.Fg = pcol("text_virtualtext_fg")
+	opt.VirtualText.Bg = pcol("text_virtualtext_bg")
+
 	// word highlight
 	opt.WordHighlight.Fg = pcol("text_highlightword_fg")
 	opt.WordHighlight.Bg = pcol("text_highlightword_bg")
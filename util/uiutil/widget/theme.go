@@ -21,6 +21,11 @@ var DefaultPalette = Palette{
 	"text_colorize_comments_bg":   nil,
 	"text_colorize_git_add_fg":    cint(0x008b00), // green
 	"text_colorize_git_delete_fg": cint(0x8b0000), // red
+	"text_colorize_keyword_fg":    cint(0x00008b), // blue
+	"text_colorize_type_fg":       cint(0x008b8b), // cyan
+	"text_colorize_function_fg":   cint(0x5c3317), // brown
+	"text_colorize_parameter_fg":  cint(0x8b008b), // magenta
+	"text_colorize_constant_fg":   cint(0xb8510b), // orange
 	"text_highlightword_fg":       nil,
 	"text_highlightword_bg":       cint(0xc6ee9e), // green
 	"text_wrapline_fg":            cint(0x0),
@@ -31,6 +36,8 @@ var DefaultPalette = Palette{
 	"text_annotations_bg":         cint(0xb0e0ef),
 	"text_annotations_select_fg":  cint(0x0),
 	"text_annotations_select_bg":  cint(0xefc7b0),
+	"text_virtualtext_fg":         cint(0x757575), // grey 600
+	"text_virtualtext_bg":         nil,
 
 	"scrollbar_bg":        cint(0xf2f2f2),
 	"scrollhandle_normal": cint(0xb2b2b2),